@@ -36,15 +36,39 @@ func As(err error, target interface{}) bool { //nolint:revive
 	// 	return false
 	// }
 	targetType := typ.Elem()
-	for err != nil {
-		if x, ok := err.(interface{ As(interface{}) bool }); ok && x.As(target) { //nolint:revive
+	return as(err, target, val, targetType, nil)
+}
+
+// as tests err and its inner errors in depth-first order, without
+// mutating any of them. The inner errors of our error objects are
+// walked by their asV, before the objects themselves.
+//
+// The visited nodes are skipped so a cyclic error tree is safe.
+func as(err error, target interface{}, val reflect.Value, targetType reflect.Type, visited visitedSet) bool { //nolint:revive
+	if err == nil || !visited.enter(err, nil) {
+		return false
+	}
+	x, ours := err.(interface {
+		asV(interface{}, reflect.Value, reflect.Type, visitedSet) bool //nolint:revive
+	})
+	if ours {
+		if x.asV(target, val, targetType, visited) {
 			return true
 		}
-		if reflect.TypeOf(err).AssignableTo(targetType) {
-			val.Elem().Set(reflect.ValueOf(err))
+	} else if x, ok := err.(interface{ As(interface{}) bool }); ok && x.As(target) { //nolint:revive
+		return true
+	}
+	if reflect.TypeOf(err).AssignableTo(targetType) {
+		val.Elem().Set(reflect.ValueOf(err))
+		return true
+	}
+	if ours {
+		return false
+	}
+	for _, e := range unwrapErrors(err) {
+		if as(e, target, val, targetType, visited) {
 			return true
 		}
-		err = Unwrap(err) //nolint:revive
 	}
 	return false
 }
@@ -64,15 +88,21 @@ func AsSlice(errs []error, target interface{}) bool { //nolint:revive
 		return false
 	}
 	targetType := typ.Elem()
+	return asSlice(errs, target, val, targetType, nil)
+}
+
+func asSlice(errs []error, target interface{}, val reflect.Value, targetType reflect.Type, visited visitedSet) bool { //nolint:revive
 	for _, err := range errs {
+		if err == nil {
+			continue
+		}
 		if reflect.TypeOf(err).AssignableTo(targetType) {
 			val.Elem().Set(reflect.ValueOf(err))
 			return true
 		}
-		if x, ok := err.(interface{ As(interface{}) bool }); ok && x.As(target) { //nolint:revive
+		if as(err, target, val, targetType, visited) {
 			return true
 		}
-		err = Unwrap(err) //nolint:ineffassign,staticcheck
 	}
 	return false
 }
@@ -101,7 +131,12 @@ func Is(err, target error) bool { //nolint:revive
 	if target == nil {
 		return err == nil
 	}
+	return isV(err, target, nil)
+}
 
+// isV is Is with a visited set, the visited nodes are skipped so a
+// cyclic error tree is safe.
+func isV(err, target error, visited visitedSet) bool { //nolint:revive
 	isComparable := reflect.TypeOf(target).Comparable()
 	tv := reflect.ValueOf(target)
 	// target is not Code-based, try convert source err with target's type, and test whether its plain text message is equal
@@ -110,10 +145,19 @@ func Is(err, target error) bool { //nolint:revive
 		savedMsg = target.Error()
 	}
 	for {
+		if !visited.enter(err, target) {
+			return false
+		}
 		if isComparable && err == target {
 			return true
 		}
-		if x, ok := err.(interface{ Is(error) bool }); ok && x.Is(target) {
+		if x, ok := err.(interface {
+			isSelf(error, visitedSet) bool
+		}); ok {
+			if x.isSelf(target, visited) {
+				return true
+			}
+		} else if x, ok := err.(interface{ Is(error) bool }); ok && x.Is(target) {
 			return true
 		}
 		if _, ok := target.(Code); !ok {
@@ -131,21 +175,19 @@ func Is(err, target error) bool { //nolint:revive
 		// 	return false
 		// }
 
-		switch x := err.(type) {
-		case interface{ Unwrap() error }:
-			err = x.Unwrap() //nolint:revive
-			if err == nil {
-				return false
-			}
-		case interface{ Unwrap() []error }:
-			for _, err := range x.Unwrap() {
-				if Is(err, target) {
+		errs := unwrapErrors(err)
+		switch len(errs) {
+		case 0:
+			return false
+		case 1:
+			err = errs[0] //nolint:revive
+		default:
+			for _, err := range errs {
+				if isV(err, target, visited) {
 					return true
 				}
 			}
 			return false
-		default:
-			return false
 		}
 	}
 }
@@ -155,29 +197,34 @@ func IsStd(err, target error) bool { //nolint:revive
 		return err == target
 	}
 
+	return isStdV(err, target, nil)
+}
+
+func isStdV(err, target error, visited visitedSet) bool { //nolint:revive
 	isComparable := reflect.TypeOf(target).Comparable()
 	for {
+		if !visited.enter(err, target) {
+			return false
+		}
 		if isComparable && err == target {
 			return true
 		}
 		if x, ok := err.(interface{ Is(error) bool }); ok && x.Is(target) {
 			return true
 		}
-		switch x := err.(type) {
-		case interface{ Unwrap() error }:
-			err = x.Unwrap() //nolint:revive
-			if err == nil {
-				return false
-			}
-		case interface{ Unwrap() []error }:
-			for _, err := range x.Unwrap() {
-				if Is(err, target) {
+		errs := unwrapErrors(err)
+		switch len(errs) {
+		case 0:
+			return false
+		case 1:
+			err = errs[0] //nolint:revive
+		default:
+			for _, err := range errs {
+				if isStdV(err, target, visited) {
 					return true
 				}
 			}
 			return false
-		default:
-			return false
 		}
 	}
 }
@@ -199,8 +246,12 @@ func Iss(errChecking error, targets ...error) (matched bool) { //nolint:revive
 		if !isNil(tv) {
 			savedMsg = target.Error()
 		}
+		var visited visitedSet
 	outer:
 		for {
+			if !visited.enter(err, target) {
+				break outer
+			}
 			if isComparable && err == target {
 				return true
 			}
@@ -221,21 +272,19 @@ func Iss(errChecking error, targets ...error) (matched bool) { //nolint:revive
 			// 	return false
 			// }
 
-			switch x := err.(type) {
-			case interface{ Unwrap() error }:
-				err = x.Unwrap() //nolint:revive
-				if err == nil {
-					break outer
-				}
-			case interface{ Unwrap() []error }:
-				for _, err := range x.Unwrap() {
+			errs := unwrapErrors(err)
+			switch len(errs) {
+			case 0:
+				break outer
+			case 1:
+				err = errs[0] //nolint:revive
+			default:
+				for _, err := range errs {
 					if Is(err, target) {
 						return true
 					}
 				}
 				break outer
-			default:
-				break outer
 				// return false
 				//
 				// here is a bug which causes the rest errors expect the first one could never be processed.
@@ -322,8 +371,15 @@ func TypeIs(err, target error) bool { //nolint:revive
 		return err == target
 	}
 
+	return typeIsV(err, target, nil)
+}
+
+func typeIsV(err, target error, visited visitedSet) bool { //nolint:revive
 	isComparable := reflect.TypeOf(target).Comparable()
 	for {
+		if !visited.enter(err, target) {
+			return false
+		}
 		if isComparable {
 			if reflect.TypeOf(target) == reflect.TypeOf(err) {
 				return true
@@ -340,21 +396,19 @@ func TypeIs(err, target error) bool { //nolint:revive
 		// 	return false
 		// }
 
-		switch x := err.(type) {
-		case interface{ Unwrap() error }:
-			err = x.Unwrap() //nolint:revive
-			if err == nil {
-				return false
-			}
-		case interface{ Unwrap() []error }:
-			for _, err := range x.Unwrap() {
-				if Is(err, target) {
+		errs := unwrapErrors(err)
+		switch len(errs) {
+		case 0:
+			return false
+		case 1:
+			err = errs[0] //nolint:revive
+		default:
+			for _, err := range errs {
+				if typeIsV(err, target, visited) {
 					return true
 				}
 			}
 			return false
-		default:
-			return false
 		}
 	}
 }
@@ -420,7 +474,15 @@ func TypeIsSlice(errs []error, target error) bool { //nolint:revive
 //	    e = errors.Unwrap(err)
 //	    // test if e is not nil and process it...
 //	}
+//
+// The one-by-one unwrapping of an errors.Error is deprecated and kept
+// for backward compatibility only. It mutates the error object so it
+// is not safe for concurrent use. Use Causes, Is and As, which traverse
+// the whole error tree without side effects.
 func Unwrap(err error) error {
+	if u, ok := err.(interface{ unwrapNext() error }); ok {
+		return u.unwrapNext()
+	}
 	u, ok := err.(interface {
 		Unwrap() error
 	})
@@ -430,9 +492,31 @@ func Unwrap(err error) error {
 	return u.Unwrap()
 }
 
+// unwrapErrors returns the direct inner errors of err without
+// mutating it.
+//
+// Our own error objects are asked by unwrapAll, others by the go1.20
+// Unwrap() []error or the classic Unwrap() error.
+func unwrapErrors(err error) []error {
+	switch x := err.(type) {
+	case interface{ unwrapAll() []error }:
+		return x.unwrapAll()
+	case interface{ Unwrap() []error }:
+		return x.Unwrap()
+	case interface{ Unwrap() error }:
+		if e := x.Unwrap(); e != nil {
+			return []error{e}
+		}
+	}
+	return nil
+}
+
 // Wrap returns an error annotating err with a Stack trace
 // at the point Wrap is called, and the supplied message.
 // If err is nil, Wrap returns nil.
+//
+// The error message is formatted as "message [err]". Use Wrapf or
+// WithMessage for the "message: err" form of pkg/errors.
 func Wrap(err error, message string, args ...interface{}) *WithStackInfo { //nolint:revive
 	if err == nil {
 		return nil
@@ -441,12 +525,21 @@ func Wrap(err error, message string, args ...interface{}) *WithStackInfo { //nol
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...) //nolint:revive
 	}
+	w := &WithStackInfo{causes2: causes2{Causers: []error{err}, msg: message}}
+	w.Stack, w.omitted = callers(1, stackOpts{}.wrapping(err))
+	return w
+}
 
+// wrap makes err as the cause of a new error object with the message
+// printed as "message: err", no stack is recorded. The text of err is
+// got while printing, so it is up to date if err changes later.
+func wrap(err error, message string) *WithStackInfo {
 	return &WithStackInfo{
 		causes2: causes2{
-			Causers: []error{err},
-			msg:     message,
+			Causers:    []error{err},
+			msg:        message,
+			msgCauses:  1,
+			wrapsCause: true,
 		},
-		Stack: callers(1),
 	}
 }
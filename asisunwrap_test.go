@@ -4,6 +4,7 @@ import (
 	// errors2 "errors"
 	"io"
 	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -150,6 +151,28 @@ func TestWrap(t *testing.T) {
 	_ = Unwrap(io.EOF)
 }
 
+func TestWrapLiveCause(t *testing.T) {
+	container := New("batch")
+	err := WithMessage(container, "save")
+	container.Attach(io.EOF)
+	if got := err.Error(); got != "save: batch [EOF]" {
+		t.Fatalf("the cause text should be got while printing: %q", got)
+	}
+
+	err = WithMessage(New("100% full"), "disk %v")
+	if got := err.(*WithStackInfo).FormatWith("sda").Error(); got != "disk sda: 100% full" {
+		t.Fatalf("FormatWith should not format the cause text: %q", got)
+	}
+
+	data, _ := MarshalJSONWith(WithMessage(io.EOF, "read"), JSONOmitStack(true))
+	if want := `{"type":"*errors.WithStackInfo","message":"read","message_causes":1,"message_wraps":true,"causes":[{"type":"*errors.errorString","message":"EOF","sentinel":"io.EOF"}]}`; string(data) != want {
+		t.Fatalf("the message should not hold the cause text:\n got %s\nwant %s", data, want)
+	}
+	if e, _ := UnmarshalJSONError(data); e == nil || e.Error() != "read: EOF" {
+		t.Fatalf("bad decoded error: %v", e)
+	}
+}
+
 func TestTypeIsSlice(t *testing.T) {
 	TypeIsSlice(nil, nil)
 	TypeIsSlice(nil, io.EOF)
@@ -253,3 +276,58 @@ func TestAsSliceRaisePanic(t *testing.T) {
 		AsSlice(nil, err)
 	})
 }
+
+func TestIsAsStateless(t *testing.T) {
+	err := New("container").WithErrors(io.EOF, io.ErrShortWrite).WithCode(NotFound)
+
+	// Is/As must not move the legacy unwrapping index
+	for i := 0; i < 3; i++ {
+		if !Is(err, io.ErrShortWrite) || !Is(err, NotFound) {
+			t.Fatalf("%d. Is() gives a different answer on repeated calls", i)
+		}
+		var code Code
+		if !As(err, &code) || code != NotFound {
+			t.Fatalf("%d. As() gives a different answer on repeated calls", i)
+		}
+	}
+	if e := Unwrap(err); e != io.EOF {
+		t.Fatalf("Unwrap() should still start from the first inner error, but got %v", e)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !Iss(err, io.ErrClosedPipe, io.EOF) {
+				t.Error("Iss() failed in goroutine")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAsSliceDeep(t *testing.T) {
+	_, e1 := strconv.Atoi("x")
+	inner := New("inner").WithErrors(io.EOF, e1)
+	var ne *strconv.NumError
+	if !AsSlice([]error{io.ErrShortWrite, New("outer").WithErrors(inner)}, &ne) {
+		t.Fatal("AsSlice() should find *strconv.NumError in nested containers")
+	}
+}
+
+// asCounter is a value-type error counting the calls of As.
+type asCounter struct{ calls *int }
+
+func (e asCounter) Error() string { return "as counter" }
+
+func (e asCounter) As(interface{}) bool { *e.calls++; return false } //nolint:revive
+
+func TestAsWalksOnce(t *testing.T) {
+	var calls int
+	err := New("outer").WithErrors(New("inner").WithErrors(asCounter{&calls}))
+	var ne *strconv.NumError
+	if As(err, &ne) || calls != 1 {
+		t.Fatalf("a value-type cause should be tested once, %d calls", calls)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"sync/atomic"
+)
+
+// CapturePolicy tells how the stack is recorded when an error is
+// made by New, Wrap, WithStack, Code.New and the others.
+//
+// Recording the full stack costs a runtime.Callers call with a
+// buffer of StackDepth frames. In a hot path, such as a parser
+// returning an error per token, a cheaper policy can be used:
+//
+//	errors.SetCapturePolicy(errors.CaptureSampled)
+//	errors.SetCaptureSampleRate(100) // the full stack for 1% errors
+type CapturePolicy int32
+
+const (
+	// CaptureDefault uses the package policy, it is the default of a
+	// call site, see SetCapturePolicy.
+	CaptureDefault CapturePolicy = iota
+	// CaptureFull records the full stack up to StackDepth frames, it
+	// is the default of the package.
+	CaptureFull
+	// CaptureCaller records the caller frame only.
+	CaptureCaller
+	// CaptureNone records nothing, the error has no stack.
+	CaptureNone
+	// CaptureSampled records the full stack every Nth error (see
+	// SetCaptureSampleRate), and the caller frame otherwise.
+	CaptureSampled
+)
+
+var capturePolicyNames = [...]string{"DEFAULT", "FULL", "CALLER", "NONE", "SAMPLED"}
+
+// String for stringer interface
+func (p CapturePolicy) String() string {
+	if p >= 0 && int(p) < len(capturePolicyNames) {
+		return capturePolicyNames[p]
+	}
+	return "UNKNOWN"
+}
+
+var (
+	capturePolicy     = int32(CaptureFull)
+	captureSampleRate = int32(100)
+	captureCounter    uint32
+)
+
+// SetCapturePolicy sets the package policy to record the stacks.
+// CaptureDefault restores CaptureFull.
+//
+// It can be overridden by a call site, see Builder.WithCapturePolicy
+// and WithCapturePolicy.
+func SetCapturePolicy(p CapturePolicy) {
+	if p == CaptureDefault {
+		p = CaptureFull
+	}
+	atomic.StoreInt32(&capturePolicy, int32(p))
+}
+
+// GetCapturePolicy returns the package policy to record the stacks.
+func GetCapturePolicy() CapturePolicy {
+	return CapturePolicy(atomic.LoadInt32(&capturePolicy))
+}
+
+// SetCaptureSampleRate sets n for CaptureSampled, the full stack is
+// recorded every n errors. It is 100 by default, n < 1 is taken as 1.
+func SetCaptureSampleRate(n int) {
+	if n < 1 {
+		n = 1
+	}
+	atomic.StoreInt32(&captureSampleRate, int32(n))
+}
+
+// resolve returns CaptureFull, CaptureCaller or CaptureNone for p,
+// the default and sampled policies are resolved.
+func (p CapturePolicy) resolve() CapturePolicy {
+	if p == CaptureDefault {
+		p = GetCapturePolicy()
+	}
+	if p == CaptureSampled {
+		n := uint32(atomic.LoadInt32(&captureSampleRate))
+		if atomic.AddUint32(&captureCounter, 1)%n == 1%n {
+			return CaptureFull
+		}
+		return CaptureCaller
+	}
+	return p
+}
+
+// WrapPolicy tells how the stack is recorded when an error which
+// carries a stack already is wrapped by Wrap, WithStack, Errorf,
+// New(WithErrors(...)) and the others.
+type WrapPolicy int32
+
+const (
+	// WrapFull records the stack by the capture policy, as for a
+	// new error. It is the default.
+	WrapFull WrapPolicy = iota
+	// WrapCaller records the caller frame of the wrapping site only,
+	// if an inner error carries a stack. The deep trace of the inner
+	// error is the authoritative one, %+v prints it after the frame
+	// of the wrapping site.
+	WrapCaller
+)
+
+var wrapPolicyNames = [...]string{"FULL", "CALLER"}
+
+// String for stringer interface
+func (p WrapPolicy) String() string {
+	if p >= 0 && int(p) < len(wrapPolicyNames) {
+		return wrapPolicyNames[p]
+	}
+	return "UNKNOWN"
+}
+
+var wrapPolicy int32
+
+// SetWrapPolicy sets how the stack is recorded when an error which
+// carries a stack already is wrapped:
+//
+//	errors.SetWrapPolicy(errors.WrapCaller)
+//	err := errors.Wrap(errors.New("inner"), "outer") // one frame recorded by Wrap
+//
+// A call site which specifies its capture policy, such as by
+// Builder.WithCapturePolicy, is not affected.
+func SetWrapPolicy(p WrapPolicy) {
+	atomic.StoreInt32(&wrapPolicy, int32(p))
+}
+
+// GetWrapPolicy returns how the stack is recorded when an error which
+// carries a stack already is wrapped.
+func GetWrapPolicy() WrapPolicy {
+	return WrapPolicy(atomic.LoadInt32(&wrapPolicy))
+}
+
+// wrapping returns o for a call site wrapping causes, see
+// SetWrapPolicy.
+func (o stackOpts) wrapping(causes ...error) stackOpts {
+	if o.policy == CaptureDefault && GetWrapPolicy() == WrapCaller && hasStack(causes) {
+		o.policy = CaptureCaller
+	}
+	return o
+}
+
+// hasStack reports whether an error in the trees of errs carries a
+// stack.
+func hasStack(errs []error) (yes bool) {
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		_ = Walk(err, func(e error, depth int, path []int, parent error) error {
+			if x, ok := e.(*WithStackInfo); ok && (x.Stack != nil || len(x.remote) > 0) {
+				yes = true
+				return SkipAll
+			}
+			return nil
+		})
+		if yes {
+			return
+		}
+	}
+	return
+}
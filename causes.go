@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 )
 
@@ -18,6 +19,9 @@ type causes2 struct {
 	maxStringLen int // the output string max-length for an object (see also sites/taggedSites), negative or zero means no limit.
 
 	liveArgs []interface{} //nolint:revive // error message template ?
+
+	msgCauses  int  // how many leading Causers have their texts in the headline already, see Errorf and Wrap
+	wrapsCause bool // the first Causer is printed after msg as "msg: cause", see Wrap
 }
 
 func (w *causes2) limitObj(obj interface{}) (s string) { //nolint:revive
@@ -81,6 +85,7 @@ func (w *causes2) Defer(err *error) { //nolint:gocritic
 
 func (w *causes2) Clear() Container {
 	w.msg = ""
+	w.msgCauses, w.wrapsCause = 0, false
 	w.Causers = nil
 	w.liveArgs = nil
 	w.Code = OK
@@ -98,9 +103,12 @@ func (w *causes2) Clear() Container {
 // be attached if it is empty (i.e. no errors).
 //
 // For a nil error object, it will be ignored.
+//
+// An error which holds w itself (directly or deeply) will be ignored
+// too, since attaching it makes a cyclic error tree.
 func (w *causes2) WithErrors(errs ...error) *causes2 { //nolint:revive
 	for _, e := range errs {
-		if e != nil {
+		if e != nil && !w.wouldCycle(e) {
 			if check, ok := e.(interface{ IsEmpty() bool }); ok {
 				if !check.IsEmpty() {
 					w.Causers = append(w.Causers, e)
@@ -115,13 +123,16 @@ func (w *causes2) WithErrors(errs ...error) *causes2 { //nolint:revive
 	return w
 }
 
-// Attach collects the errors except it's nil
+// Attach collects the errors except it's nil.
+//
+// Attaching w itself, or an error which holds w, is denied since it
+// makes a cyclic error tree.
 func (w *causes2) Attach(errs ...error) {
 	// _ = w.WithErrors(errs...)
 
 	for _, e := range errs {
 		if e != nil {
-			if x, ok := e.(*causes2); ok && x == w {
+			if w.wouldCycle(e) {
 				continue
 			}
 			w.Causers = append(w.Causers, e)
@@ -144,6 +155,8 @@ func (w *causes2) Clone() *causes2 {
 		msg:         w.msg,
 		unwrapIndex: w.unwrapIndex,
 		liveArgs:    w.liveArgs,
+		msgCauses:   w.msgCauses,
+		wrapsCause:  w.wrapsCause,
 	}
 	return c
 }
@@ -152,17 +165,43 @@ func (w *causes2) Error() string {
 	return w.makeErrorString(false)
 }
 
+// message returns the message of w, formatted with the live args
+// given by FormatWith.
+func (w *causes2) message() string {
+	if len(w.liveArgs) > 0 && w.msg != "" {
+		return fmt.Sprintf(w.msg, w.liveArgs...)
+	}
+	return w.msg
+}
+
+// headlineV returns the message of w, followed by the text of the
+// first cause if w wraps it, see Wrap.
+func (w *causes2) headlineV(visited visitedSet) string {
+	msg := w.message()
+	if !w.wrapsCause || len(w.Causers) == 0 || w.Causers[0] == nil {
+		return msg
+	}
+	cause := errorStringV(w.Causers[0], visited)
+	if msg == "" {
+		return cause
+	}
+	return msg + ": " + cause
+}
+
 func (w *causes2) makeErrorString(line bool) string { //nolint:revive
-	var buf bytes.Buffer
-	if w.msg != "" {
-		if len(w.liveArgs) > 0 {
-			msg := fmt.Sprintf(w.msg, w.liveArgs...)
-			_, _ = buf.WriteString(msg)
-		} else {
-			_, _ = buf.WriteString(w.msg)
-		}
+	return w.makeErrorStringV(line, make(visitedSet))
+}
+
+// makeErrorStringV formats w with the ancestors being formatted in
+// visited, a cyclic reference is printed as cycleMarker.
+func (w *causes2) makeErrorStringV(line bool, visited visitedSet) string { //nolint:revive
+	if !visited.enter(w, nil) {
+		return cycleMarker
 	}
+	defer visited.leave(w, nil)
 
+	var buf bytes.Buffer
+	_, _ = buf.WriteString(w.headlineV(visited))
 	if line {
 		_, _ = buf.WriteRune('\n')
 		if w.Code != OK {
@@ -174,7 +213,7 @@ func (w *causes2) makeErrorString(line bool) string { //nolint:revive
 			_, _ = buf.WriteString("  - ")
 			var xc *causes2
 			if As(c, &xc) {
-				_, _ = buf.WriteString(leftPad(xc.makeErrorString(line), "  ", false))
+				_, _ = buf.WriteString(leftPad(xc.makeErrorStringV(line, visited), "  ", false))
 			} else {
 				_, _ = buf.WriteString(leftPad(c.Error(), "    ", false))
 			}
@@ -197,7 +236,11 @@ func (w *causes2) makeErrorString(line bool) string { //nolint:revive
 	if w.msg == "" {
 		needsep = false
 	}
-	if len(w.Causers) > 0 {
+	causes := w.Causers
+	if w.msgCauses > 0 && w.msgCauses <= len(causes) {
+		causes = causes[w.msgCauses:]
+	}
+	if len(causes) > 0 {
 		if buf.Len() > 0 {
 			_, _ = buf.WriteRune(' ')
 		}
@@ -205,11 +248,11 @@ func (w *causes2) makeErrorString(line bool) string { //nolint:revive
 		needclose = true
 	}
 
-	for i, c := range w.Causers {
+	for i, c := range causes {
 		if i > 0 || needsep {
 			_, _ = buf.WriteString(" | ")
 		}
-		_, _ = buf.WriteString(c.Error())
+		_, _ = buf.WriteString(errorStringV(c, visited))
 	}
 	if needclose {
 		_, _ = buf.WriteString("]")
@@ -218,6 +261,15 @@ func (w *causes2) makeErrorString(line bool) string { //nolint:revive
 	return buf.String()
 }
 
+// errorStringV returns err.Error(), or cycleMarker if err is being
+// formatted by one of its ancestors.
+func errorStringV(err error, visited visitedSet) string {
+	if x, ok := err.(interface{ self() *causes2 }); ok {
+		return x.self().makeErrorStringV(false, visited)
+	}
+	return err.Error()
+}
+
 func leftPad(s, padStr string, firstLine bool) string { //nolint:revive
 	if padStr == "" {
 		return s
@@ -273,14 +325,14 @@ func (w *causes2) Cause() error {
 // Causes simply returns the wrapped inner errors.
 // It doesn't consider an wrapped Code entity is an inner error too.
 // So if you wanna to extract any inner error objects, use
-// errors.Unwrap for instead. The errors.Unwrap could extract all
-// of them one by one:
+// errors.Walk for instead. The errors.Walk visits all of them,
+// including the nested ones:
 //
 //	var err = errors.New("hello").WithErrors(io.EOF, io.ShortBuffers)
-//	var e error = err
-//	for e != nil {
-//	    e = errors.Unwrap(err)
-//	}
+//	_ = errors.Walk(err, func(e error, depth int, path []int, parent error) error {
+//	    // process e...
+//	    return nil
+//	})
 func (w *causes2) Causes() []error {
 	if len(w.Causers) == 0 {
 		return nil
@@ -288,7 +340,27 @@ func (w *causes2) Causes() []error {
 	return w.Causers
 }
 
-func (w *causes2) Unwrap() error {
+// unwrapAll returns the inner errors and the non-OK Code, in the
+// order the deprecated single-step Unwrap used to visit them.
+//
+// It never touches the unwrapping index, so it is safe for concurrent
+// use and can be called any number of times.
+func (w *causes2) unwrapAll() []error {
+	if w.Code == OK {
+		return w.Causes()
+	}
+	errs := make([]error, 0, len(w.Causers)+1)
+	errs = append(errs, w.Causers...)
+	return append(errs, w.Code)
+}
+
+// unwrapNext is the stateful single-step unwrapper used by the
+// package-level Unwrap for backward compatibility.
+//
+// Deprecated: it mutates the error object and races when shared
+// between goroutines. Use Causes, Is, As or the go1.20 shape
+// Unwrap() []error instead.
+func (w *causes2) unwrapNext() error {
 	defer func() { w.unwrapIndex++ }()
 
 	if w.unwrapIndex >= 0 && w.unwrapIndex < len(w.Causers) {
@@ -305,6 +377,9 @@ func (w *causes2) Unwrap() error {
 	return nil
 }
 
+// Reset rewinds the internal index used by the single-step Unwrap.
+//
+// Deprecated: only the legacy errors.Unwrap iteration needs it.
 func (w *causes2) Reset() {
 	w.unwrapIndex = 0
 }
@@ -326,33 +401,45 @@ func (w *causes2) IsDescended(descendant error) bool {
 }
 
 func (w *causes2) Is(target error) bool {
+	var visited visitedSet
+	_ = visited.enter(w, target)
+	if w.isSelf(target, visited) {
+		return true
+	}
+	for _, e := range w.Causers {
+		if isV(e, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSelf tests target against w itself but not its inner errors.
+func (w *causes2) isSelf(target error, visited visitedSet) bool {
 	if w.Code != OK {
 		if c, ok := target.(Code); ok && c == w.Code {
 			return true
 		}
 	}
-	if IsSlice(w.Causers, target) {
-		return true
-	}
 	if te, ok := target.(*causes2); ok {
-		return w.equal(te)
+		return w.equal(te, visited)
 	}
 	return false
 }
 
-func (w *causes2) equal(target *causes2) bool {
+func (w *causes2) equal(target *causes2, visited visitedSet) bool {
 	return w.Code == target.Code &&
 		w.msg == target.msg &&
-		w.arrayEqual(w.Causers, target.Causers)
+		w.arrayEqual(w.Causers, target.Causers, visited)
 }
 
-func (w *causes2) arrayEqual(a, b []error) bool {
+func (w *causes2) arrayEqual(a, b []error, visited visitedSet) bool {
 	if len(a) != len(b) {
 		return false
 	}
 	yes := true
 	for i := 0; i < len(a); i++ {
-		if Is(a[i], b[i]) {
+		if isV(a[i], b[i], visited) {
 			yes = false
 			break
 		}
@@ -367,6 +454,24 @@ func (w *causes2) TypeIs(target error) bool {
 // As finds the first error in `err`'s chain that matches target,
 // and if so, sets target to that error value and returns true.
 func (w *causes2) As(target interface{}) bool { //nolint:revive
+	if w.asSelf(target) {
+		return true
+	}
+	return AsSlice(w.Causers, target)
+}
+
+// asV is As with a visited set, it tests w and walks its inner
+// errors, see also as().
+func (w *causes2) asV(target interface{}, val reflect.Value, targetType reflect.Type, visited visitedSet) bool { //nolint:revive
+	if w.asSelf(target) {
+		return true
+	}
+	return asSlice(w.Causers, target, val, targetType, visited)
+}
+
+// asSelf sets target to w itself, its Code or its inner errors slice
+// if the type of target matched.
+func (w *causes2) asSelf(target interface{}) bool { //nolint:revive
 	if c, ok := target.(*Code); ok {
 		*c = w.Code
 		return true
@@ -379,7 +484,7 @@ func (w *causes2) As(target interface{}) bool { //nolint:revive
 		*c = w
 		return true
 	}
-	return AsSlice(w.Causers, target)
+	return false
 }
 
 // IsEmpty tests has attached errors
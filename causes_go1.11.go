@@ -0,0 +1,18 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build !go1.20
+// +build !go1.20
+
+package errors
+
+// Unwrap extracts the inner errors one by one, and the Code at last.
+// It returns nil once all of them have been extracted, and restarts
+// from the first one on the next call.
+//
+// Before go1.20, stdlib errors package understands Unwrap() error
+// only, so we keep the single-step shape here.
+//
+// Deprecated: it mutates the error object. Use Causes, Is and As.
+func (w *causes2) Unwrap() error {
+	return w.unwrapNext()
+}
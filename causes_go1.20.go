@@ -0,0 +1,15 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+// Unwrap returns the inner errors and the non-OK Code, so the error
+// object can be inspected by stdlib errors.Is and errors.As (go1.20+).
+//
+// Unwrap is stateless, calling it concurrently or repeatedly always
+// gives the same answer.
+func (w *causes2) Unwrap() []error {
+	return w.unwrapAll()
+}
@@ -0,0 +1,50 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.20
+// +build go1.20
+
+package errors_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	v3 "gopkg.in/hedzr/errors.v3"
+)
+
+func TestUnwrapSliceStd(t *testing.T) {
+	err := v3.New("container").WithErrors(io.EOF, io.ErrShortWrite).WithCode(v3.NotFound)
+
+	u, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatal("expecting Unwrap() []error on v3 error")
+	}
+	if errs := u.Unwrap(); len(errs) != 3 {
+		t.Fatalf("expecting 2 inner errors and a code, but got %v", errs)
+	}
+
+	for _, target := range []error{io.EOF, io.ErrShortWrite, v3.NotFound} {
+		if !errors.Is(err, target) {
+			t.Fatalf("stdlib errors.Is(err, %v) failed", target)
+		}
+	}
+	var code v3.Code
+	if !errors.As(err, &code) || code != v3.NotFound {
+		t.Fatalf("stdlib errors.As(err, &code) failed, code = %v", code)
+	}
+}
+
+func TestErrorfMultiple(t *testing.T) {
+	err := v3.Errorf("%w, and %w", io.EOF, io.ErrShortWrite)
+	if expect := fmt.Errorf("%w, and %w", io.EOF, io.ErrShortWrite).Error(); err.Error() != expect {
+		t.Fatalf("expecting %q, but got %q", expect, err.Error())
+	}
+	if !v3.Is(err, io.EOF) || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatal("expecting err is io.EOF and io.ErrShortWrite")
+	}
+	if causes := v3.Causes(err); len(causes) != 2 {
+		t.Fatalf("expecting 2 inner errors, but got %v", causes)
+	}
+}
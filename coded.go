@@ -278,9 +278,10 @@ var codeToStr = map[Code]string{
 // ----------------------------
 //
 
-// New create a new *CodedErr object based an error code
+// New create a new *CodedErr object based an error code. The stack is
+// recorded from the caller of New.
 func (c Code) New(msg string, args ...interface{}) Buildable { //nolint:revive
-	return Message(msg, args...).WithCode(c).Build()
+	return Message(msg, args...).WithSkip(2).WithCode(c).Build()
 }
 
 // WithCode for error interface
@@ -294,6 +295,8 @@ func (c Code) Error() string { return c.String() }
 
 // String for stringer interface
 func (c Code) String() string {
+	registry.RLock()
+	defer registry.RUnlock()
 	if x, ok := codeToStr[c]; ok {
 		return x
 	}
@@ -341,16 +344,35 @@ func (c Code) Format(s fmt.State, verb rune) {
 	}
 }
 
-// Register registers a code and its token string for using later
-func (c Code) Register(codeName string) (errno Code) {
+// RelevantCode returns the most relevant Code in the error tree of
+// err, that is, the non-OK Code nearest to the root. Of the codes at
+// the same depth, the first one visited by Walk wins.
+//
+// It returns Unknown and false if there is no such Code.
+func RelevantCode(err error) (code Code, ok bool) {
+	code, minDepth := Unknown, -1
+	_ = Walk(err, func(e error, depth int, path []int, parent error) error {
+		if minDepth >= 0 && depth >= minDepth {
+			return SkipChildren
+		}
+		if c, ok := e.(Code); ok && c != OK {
+			code, minDepth = c, depth
+		}
+		return nil
+	})
+	return code, minDepth >= 0
+}
+
+// Register registers a code and its token string for using later,
+// the optional CodeInfo describes the code in detail.
+//
+// It returns OK if registered, or AlreadyExists if the name or the
+// number has been used. It is safe for concurrent use.
+func (c Code) Register(codeName string, info ...CodeInfo) (errno Code) {
 	errno = AlreadyExists
 	if c <= MinErrorCode || c > 0 {
-		if _, ok := strToCode[codeName]; !ok {
-			if _, ok = codeToStr[c]; !ok {
-				strToCode[codeName] = c
-				codeToStr[c] = codeName
-				errno = OK
-			}
+		if _, err := registry.register(c, codeName, callerPackage(1), info...); err == nil {
+			errno = OK
 		}
 	}
 	return
@@ -376,24 +398,21 @@ func (c Code) Register(codeName string) (errno Code) {
 //
 //	var ErrAck = errors.RegisterCode(3, "cannot ack")     // ErrAck will be -1003
 //	var ErrAck = errors.RegisterCode(-1003, "cannot ack)  // equivalent with last line
-func RegisterCode(codePositive int, codeName string) (errno Code) {
+//
+// The optional CodeInfo describes the code in detail:
+//
+//	var ErrQuota = errors.RegisterCode(4, "QUOTA_EXCEEDED", errors.CodeInfo{
+//	    Description: "The quota of the account is exceeded.",
+//	    HTTPStatus:  429,
+//	    Retryable:   true,
+//	})
+//
+// RegisterCode is safe for concurrent use. Use TryRegisterCode to
+// get the detailed reason of a conflict.
+func RegisterCode(codePositive int, codeName string, info ...CodeInfo) (errno Code) {
 	errno = AlreadyExists
-	applier := func(c Code) {
-		if v, ok := strToCode[codeName]; !ok {
-			if _, ok = codeToStr[c]; !ok {
-				strToCode[codeName] = c
-				codeToStr[c] = codeName
-				errno = c
-			}
-		} else {
-			errno = v
-		}
-	}
-	if codePositive > 0 {
-		c := MinErrorCode - Code(codePositive)
-		applier(c)
-	} else if c := Code(codePositive); c < MinErrorCode {
-		applier(c)
+	if codePositive > 0 || Code(codePositive) < MinErrorCode {
+		errno, _ = tryRegisterCode(codePositive, codeName, callerPackage(1), info...)
 	}
 	return
 }
@@ -3,6 +3,7 @@ package errors
 import (
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 )
 
@@ -100,3 +101,29 @@ func TestCodesRegister(t *testing.T) {
 	t.Log(illegalStateEx)
 	t.Logf("%+v", illegalStateEx)
 }
+
+func TestCodeNewCaller(t *testing.T) {
+	err := Internal.New("coded")
+	if f := err.(*WithStackInfo).Frames()[0]; !strings.HasSuffix(f.Function, ".TestCodeNewCaller") {
+		t.Fatalf("Code.New should record from its caller: %v", f.Function)
+	}
+}
+
+func TestRelevantCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Code
+		ok   bool
+	}{
+		{nil, Unknown, false},
+		{io.EOF, Unknown, false},
+		{OK, Unknown, false},
+		{New("outer").WithErrors(Internal.New("deep"), io.EOF).WithCode(NotFound), NotFound, true},
+		{New("outer").WithErrors(io.EOF, Forbidden.New("inner"), Conflict), Conflict, true},
+	}
+	for i, tt := range tests {
+		if got, ok := RelevantCode(tt.err); got != tt.want || ok != tt.ok {
+			t.Errorf("%d. RelevantCode(%v) = %v, %v, want %v, %v", i, tt.err, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+// Severity tells how serious an error Code is.
+type Severity int
+
+const (
+	// SeverityUnspecified is treated as SeverityError on registering.
+	SeverityUnspecified Severity = iota
+	// SeverityInfo is for the codes which are not failures, such as OK.
+	SeverityInfo
+	// SeverityWarning is for the failures caused by clients or by a
+	// transient condition.
+	SeverityWarning
+	// SeverityError is for the failures of the service itself.
+	SeverityError
+	// SeverityCritical is for the failures which need an immediate
+	// attention, such as data loss.
+	SeverityCritical
+)
+
+var severityNames = [...]string{"UNSPECIFIED", "INFO", "WARNING", "ERROR", "CRITICAL"}
+
+// String for stringer interface
+func (s Severity) String() string {
+	if s >= 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return "UNKNOWN"
+}
+
+// CodeInfo holds the metadata of a Code.
+//
+// The zero fields of a CodeInfo given to RegisterCode are filled with
+// the defaults: DisplayName with the code name, HTTPStatus with 500,
+// GRPCCode with 2 (UNKNOWN) and Severity with SeverityError.
+type CodeInfo struct {
+	Code        Code
+	Name        string // canonical name, the same as Code.String()
+	DisplayName string // human-readable name, such as "Not Found"
+	Description string // human-readable description
+	HTTPStatus  int    // the HTTP status code, 500 if not specified
+	GRPCCode    int    // the numeric gRPC code, 2 (UNKNOWN) if not specified
+	Retryable   bool   // the failed operation can be retried, the condition is temporary
+	Severity    Severity
+	DocURL      string // the documentation URL
+}
+
+const (
+	defaultHTTPStatus = 500 // http.StatusInternalServerError
+	defaultGRPCCode   = 2   // codes.Unknown
+	builtinDocURL     = "https://pkg.go.dev/gopkg.in/hedzr/errors.v3#"
+)
+
+// codeInfos holds the CodeInfo of the registered codes, guarded by
+// registry.
+var codeInfos = make(map[Code]CodeInfo)
+
+// docURLToCode maps a DocURL to the first code registered with it,
+// guarded by registry. See Problem.Code.
+var docURLToCode = make(map[string]Code)
+
+func init() { //nolint:gochecknoinits
+	for _, info := range builtinCodeInfos {
+		info.Name = codeToStr[info.Code]
+		info.DocURL = builtinDocURL + info.DocURL
+		codeInfos[info.Code] = info
+		docURLToCode[info.DocURL] = info.Code
+	}
+}
+
+// setInfo normalizes and saves the info of c, the lock must be held.
+func (r *codeRegistry) setInfo(c Code, codeName string, infos []CodeInfo) {
+	info := CodeInfo{}
+	if len(infos) > 0 {
+		info = infos[len(infos)-1]
+	} else if _, ok := codeInfos[c]; ok {
+		return
+	}
+	info.Code, info.Name = c, codeName
+	if info.DisplayName == "" {
+		info.DisplayName = codeName
+	}
+	if info.HTTPStatus == 0 {
+		info.HTTPStatus = defaultHTTPStatus
+	}
+	if info.GRPCCode == 0 {
+		info.GRPCCode = defaultGRPCCode
+	}
+	if info.Severity == SeverityUnspecified {
+		info.Severity = SeverityError
+	}
+	if old, ok := codeInfos[c]; ok && old.DocURL != info.DocURL && docURLToCode[old.DocURL] == c {
+		delete(docURLToCode, old.DocURL)
+	}
+	if _, ok := docURLToCode[info.DocURL]; !ok && info.DocURL != "" {
+		docURLToCode[info.DocURL] = c
+	}
+	codeInfos[c] = info
+}
+
+// LookupCodeInfo returns the CodeInfo of c if it is registered.
+func LookupCodeInfo(c Code) (info CodeInfo, ok bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	info, ok = codeInfos[c]
+	return
+}
+
+// LookupCode returns the Code registered with name.
+func LookupCode(name string) (c Code, ok bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	c, ok = strToCode[name]
+	return
+}
+
+// Info returns the CodeInfo of c. For an unregistered code, the
+// info of Unknown is returned with the Code field set to c.
+func (c Code) Info() CodeInfo {
+	if info, ok := LookupCodeInfo(c); ok {
+		return info
+	}
+	info, _ := LookupCodeInfo(Unknown)
+	info.Code = c
+	return info
+}
+
+// HTTPStatus returns the HTTP status code mapped from c.
+func (c Code) HTTPStatus() int { return c.Info().HTTPStatus }
+
+// GRPCCode returns the numeric gRPC code mapped from c.
+func (c Code) GRPCCode() int { return c.Info().GRPCCode }
+
+// Retryable tests whether the operation failed with c can be retried.
+func (c Code) Retryable() bool { return c.Info().Retryable }
+
+// builtinCodeInfos lists the metadata of builtin codes, the Name is
+// filled from codeToStr, and DocURL is the anchor in the godoc.
+var builtinCodeInfos = []CodeInfo{
+	{Code: OK, DisplayName: "OK", Description: "The operation completed successfully.",
+		HTTPStatus: 200, GRPCCode: 0, Severity: SeverityInfo, DocURL: "OK"},
+	{Code: Canceled, DisplayName: "Canceled", Description: "The operation was canceled, typically by the caller.",
+		HTTPStatus: 499, GRPCCode: 1, Severity: SeverityWarning, DocURL: "Canceled"},
+	{Code: Unknown, DisplayName: "Unknown", Description: "Unknown error.",
+		HTTPStatus: 500, GRPCCode: 2, Severity: SeverityError, DocURL: "Unknown"},
+	{Code: InvalidArgument, DisplayName: "Invalid Argument", Description: "The client specified an invalid argument.",
+		HTTPStatus: 400, GRPCCode: 3, Severity: SeverityWarning, DocURL: "InvalidArgument"},
+	{Code: DeadlineExceeded, DisplayName: "Deadline Exceeded", Description: "The operation expired before completion.",
+		HTTPStatus: 408, GRPCCode: 4, Retryable: true, Severity: SeverityWarning, DocURL: "DeadlineExceeded"},
+	{Code: NotFound, DisplayName: "Not Found", Description: "Some requested entity wasn't found.",
+		HTTPStatus: 404, GRPCCode: 5, Severity: SeverityWarning, DocURL: "NotFound"},
+	{Code: AlreadyExists, DisplayName: "Already Exists", Description: "The entity that a client attempted to create already exists.",
+		HTTPStatus: 409, GRPCCode: 6, Severity: SeverityWarning, DocURL: "AlreadyExists"},
+	{Code: PermissionDenied, DisplayName: "Permission Denied", Description: "The caller does not have permission to execute the specified operation.",
+		HTTPStatus: 403, GRPCCode: 7, Severity: SeverityWarning, DocURL: "PermissionDenied"},
+	{Code: ResourceExhausted, DisplayName: "Resource Exhausted", Description: "Some resource has been exhausted.",
+		HTTPStatus: 429, GRPCCode: 8, Retryable: true, Severity: SeverityWarning, DocURL: "ResourceExhausted"},
+	{Code: FailedPrecondition, DisplayName: "Failed Precondition", Description: "The system is not in a state required for the operation's execution.",
+		HTTPStatus: 400, GRPCCode: 9, Severity: SeverityWarning, DocURL: "FailedPrecondition"},
+	{Code: Aborted, DisplayName: "Aborted", Description: "The operation was aborted, typically due to a concurrency issue.",
+		HTTPStatus: 409, GRPCCode: 10, Retryable: true, Severity: SeverityWarning, DocURL: "Aborted"},
+	{Code: OutOfRange, DisplayName: "Out Of Range", Description: "The operation was attempted past the valid range.",
+		HTTPStatus: 400, GRPCCode: 11, Severity: SeverityWarning, DocURL: "OutOfRange"},
+	{Code: Unimplemented, DisplayName: "Unimplemented", Description: "The operation is not implemented or not supported/enabled.",
+		HTTPStatus: 501, GRPCCode: 12, Severity: SeverityError, DocURL: "Unimplemented"},
+	{Code: Internal, DisplayName: "Internal", Description: "Some invariants expected by underlying system has been broken.",
+		HTTPStatus: 500, GRPCCode: 13, Severity: SeverityError, DocURL: "Internal"},
+	{Code: Unavailable, DisplayName: "Unavailable", Description: "The service is currently unavailable.",
+		HTTPStatus: 503, GRPCCode: 14, Retryable: true, Severity: SeverityWarning, DocURL: "Unavailable"},
+	{Code: DataLoss, DisplayName: "Data Loss", Description: "Unrecoverable data loss or corruption.",
+		HTTPStatus: 500, GRPCCode: 15, Severity: SeverityCritical, DocURL: "DataLoss"},
+	{Code: Unauthenticated, DisplayName: "Unauthenticated", Description: "The request does not have valid authentication credentials.",
+		HTTPStatus: 401, GRPCCode: 16, Severity: SeverityWarning, DocURL: "Unauthenticated"},
+	{Code: RateLimited, DisplayName: "Rate Limited", Description: "Some flow control algorithm is running and applied.",
+		HTTPStatus: 429, GRPCCode: 8, Retryable: true, Severity: SeverityWarning, DocURL: "RateLimited"},
+	{Code: BadRequest, DisplayName: "Bad Request", Description: "The request is malformed.",
+		HTTPStatus: 400, GRPCCode: 3, Severity: SeverityWarning, DocURL: "BadRequest"},
+	{Code: Conflict, DisplayName: "Conflict", Description: "The request conflicts with the current state of the target resource.",
+		HTTPStatus: 409, GRPCCode: 10, Severity: SeverityWarning, DocURL: "Conflict"},
+	{Code: Forbidden, DisplayName: "Forbidden", Description: "The server refuses to authorize the request.",
+		HTTPStatus: 403, GRPCCode: 7, Severity: SeverityWarning, DocURL: "Forbidden"},
+	{Code: InternalServerError, DisplayName: "Internal Server Error", Description: "The server encountered an unexpected condition.",
+		HTTPStatus: 500, GRPCCode: 13, Severity: SeverityError, DocURL: "InternalServerError"},
+	{Code: MethodNotAllowed, DisplayName: "Method Not Allowed", Description: "The request method is not supported by the target resource.",
+		HTTPStatus: 405, GRPCCode: 12, Severity: SeverityWarning, DocURL: "MethodNotAllowed"},
+	{Code: Timeout, DisplayName: "Timeout", Description: "The operation timed out.",
+		HTTPStatus: 408, GRPCCode: 4, Retryable: true, Severity: SeverityWarning, DocURL: "Timeout"},
+	{Code: IllegalState, DisplayName: "Illegal State", Description: "The application is entering a bad state.",
+		HTTPStatus: 500, GRPCCode: 9, Severity: SeverityError, DocURL: "IllegalState"},
+	{Code: IllegalFormat, DisplayName: "Illegal Format", Description: "Formatting, parsing or analysis of the input failed.",
+		HTTPStatus: 400, GRPCCode: 3, Severity: SeverityWarning, DocURL: "IllegalFormat"},
+	{Code: IllegalArgument, DisplayName: "Illegal Argument", Description: "The application got an invalid argument.",
+		HTTPStatus: 400, GRPCCode: 3, Severity: SeverityWarning, DocURL: "IllegalArgument"},
+	{Code: InitializationFailed, DisplayName: "Initialization Failed", Description: "The application started up unsuccessfully.",
+		HTTPStatus: 500, GRPCCode: 13, Severity: SeverityCritical, DocURL: "InitializationFailed"},
+	{Code: DataUnavailable, DisplayName: "Data Unavailable", Description: "The data fetching failed.",
+		HTTPStatus: 503, GRPCCode: 14, Retryable: true, Severity: SeverityWarning, DocURL: "DataUnavailable"},
+	{Code: UnsupportedOperation, DisplayName: "Unsupported Operation", Description: "The operation is not supported by the application.",
+		HTTPStatus: 501, GRPCCode: 12, Severity: SeverityWarning, DocURL: "UnsupportedOperation"},
+	{Code: UnsupportedVersion, DisplayName: "Unsupported Version", Description: "The version is not supported.",
+		HTTPStatus: 400, GRPCCode: 9, Severity: SeverityWarning, DocURL: "UnsupportedVersion"},
+}
@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"testing"
+)
+
+func TestBuiltinCodeInfos(t *testing.T) {
+	for c := OK; c >= UnsupportedVersion; c-- {
+		name := c.String()
+		info, ok := LookupCodeInfo(c)
+		if !ok {
+			t.Fatalf("builtin code %v (%d) has no info", name, int(c))
+		}
+		if info.Name != name || info.Code != c || info.DisplayName == "" || info.Description == "" || info.HTTPStatus == 0 || info.DocURL == "" {
+			t.Fatalf("bad info for %v: %+v", name, info)
+		}
+		if c >= Unauthenticated && c < OK && info.GRPCCode != -int(c) {
+			t.Fatalf("bad gRPC code for %v: %d", name, info.GRPCCode)
+		}
+	}
+
+	if NotFound.HTTPStatus() != 404 || NotFound.GRPCCode() != 5 || NotFound.Retryable() {
+		t.Fatalf("bad info for NotFound: %+v", NotFound.Info())
+	}
+	if !Unavailable.Retryable() || DataLoss.Info().Severity != SeverityCritical {
+		t.Fatal("bad info for Unavailable or DataLoss")
+	}
+	if c, ok := LookupCode("NOT_FOUND"); !ok || c != NotFound {
+		t.Fatalf("LookupCode failed: %v", c)
+	}
+}
+
+func TestRegisterCodeInfo(t *testing.T) {
+	c := RegisterCode(601, "QUOTA_EXCEEDED", CodeInfo{
+		Description: "The quota of the account is exceeded.",
+		HTTPStatus:  429,
+		Retryable:   true,
+	})
+	info := c.Info()
+	if info.Code != c || info.Name != "QUOTA_EXCEEDED" || info.DisplayName != "QUOTA_EXCEEDED" ||
+		info.HTTPStatus != 429 || info.GRPCCode != 2 || !info.Retryable || info.Severity != SeverityError {
+		t.Fatalf("bad info: %+v", info)
+	}
+
+	// update the info
+	_ = RegisterCode(601, "QUOTA_EXCEEDED", CodeInfo{DocURL: "https://example.com/quota", HTTPStatus: 403})
+	if info = c.Info(); info.DocURL != "https://example.com/quota" || info.HTTPStatus != 403 {
+		t.Fatalf("bad updated info: %+v", info)
+	}
+	// registering without info keeps it
+	_ = RegisterCode(601, "QUOTA_EXCEEDED")
+	if info = c.Info(); info.HTTPStatus != 403 {
+		t.Fatalf("bad kept info: %+v", info)
+	}
+
+	c = RegisterCode(602, "NO_INFO")
+	if info = c.Info(); info.HTTPStatus != 500 || info.Name != "NO_INFO" {
+		t.Fatalf("bad default info: %+v", info)
+	}
+	if info = Code(-99999).Info(); info.Code != -99999 || info.Name != "UNKNOWN" {
+		t.Fatalf("bad info for an unregistered code: %+v", info)
+	}
+	t.Log(SeverityWarning, Severity(99))
+}
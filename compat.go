@@ -0,0 +1,106 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"fmt"
+)
+
+// StackTracer is implemented by the errors which carry a Stack trace,
+// such as *WithStackInfo (by its embedded *Stack). It is compatible
+// with pkg/errors:
+//
+//	if st, ok := err.(errors.StackTracer); ok {
+//	    fmt.Printf("%+v", st.StackTrace())
+//	}
+type StackTracer interface {
+	// StackTrace returns the stacktrace frames
+	StackTrace() StackTrace
+}
+
+var _ StackTracer = (*WithStackInfo)(nil)
+
+// Cause returns the underlying cause of the error, if possible.
+// It unwraps the Cause() chain recursively, and returns the last one,
+// just like pkg/errors.Cause does.
+//
+// An error value has a cause if it implements the following
+// interface:
+//
+//	type causer interface {
+//	       Cause() error
+//	}
+//
+// If the error does not implement Cause, the original error will
+// be returned. If the error is nil, nil will be returned without
+// further investigation.
+func Cause(err error) error {
+	var visited visitedSet
+	for err != nil && visited.enter(err, nil) {
+		c := Cause1(err)
+		if c == nil {
+			break
+		}
+		err = c
+	}
+	return err
+}
+
+// Cause1 unwraps just one level of the Cause() chain. It returns nil
+// if err has no cause.
+func Cause1(err error) error {
+	if c, ok := err.(causer); ok {
+		if e := c.Cause(); e != nil && !sameError(e, err) {
+			return e
+		}
+	}
+	return nil
+}
+
+// WithCause returns an error annotating cause with a Stack trace at
+// the point WithCause is called, and the formatted message.
+// If cause is nil, WithCause returns nil.
+//
+// WithCause is like Wrap, but the error message is formatted as
+// "message: cause", just like pkg/errors does.
+func WithCause(cause error, message string, args ...interface{}) error { //nolint:revive
+	if cause == nil {
+		return nil
+	}
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...) //nolint:revive
+	}
+	w := wrap(cause, message)
+	w.Stack, w.omitted = callers(1, stackOpts{}.wrapping(cause))
+	return w
+}
+
+// Wrapf returns an error annotating err with a Stack trace at the
+// point Wrapf is called, and the format specifier. The error message
+// is formatted as "message: err". If err is nil, Wrapf returns nil.
+func Wrapf(err error, format string, args ...interface{}) error { //nolint:revive
+	if err == nil {
+		return nil
+	}
+	w := wrap(err, fmt.Sprintf(format, args...))
+	w.Stack, w.omitted = callers(1, stackOpts{}.wrapping(err))
+	return w
+}
+
+// WithMessage annotates err with a new message, no Stack trace is
+// recorded. If err is nil, WithMessage returns nil.
+func WithMessage(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return wrap(err, message)
+}
+
+// WithMessagef annotates err with the format specifier, no Stack trace
+// is recorded. If err is nil, WithMessagef returns nil.
+func WithMessagef(err error, format string, args ...interface{}) error { //nolint:revive
+	if err == nil {
+		return nil
+	}
+	return wrap(err, fmt.Sprintf(format, args...))
+}
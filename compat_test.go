@@ -0,0 +1,138 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+)
+
+func testFormatRegexp(t *testing.T, n int, arg interface{}, format, want string) { //nolint:revive
+	t.Helper()
+	got := fmt.Sprintf(format, arg)
+	if !regexp.MustCompile("(?s)^" + want + "$").MatchString(got) {
+		t.Errorf("test %d: fmt.Sprintf(%q, err):\n got: %q\nwant: %q", n+1, format, got, want)
+	}
+}
+
+func TestFormatWrap(t *testing.T) {
+	tests := []struct {
+		error
+		format string
+		want   string
+	}{{
+		WithMessage(New("error"), "error2"),
+		"%s",
+		"error2: error",
+	}, {
+		WithMessage(New("error"), "error2"),
+		"%v",
+		"error2: error",
+	}, {
+		WithMessage(New("error"), "error2"),
+		"%q",
+		`"error2: error"`,
+	}, {
+		Wrapf(io.EOF, "error"),
+		"%+v",
+		"error: EOF\n.*" +
+			"\\.TestFormatWrap\n\t.+/compat_test.go:\\d+.*",
+	}, {
+		Wrapf(io.EOF, "error%d", 2),
+		"%s",
+		"error2: EOF",
+	}, {
+		Wrapf(io.EOF, "error%d", 2),
+		"%+v",
+		"error2: EOF\n.*" +
+			"\\.TestFormatWrap\n\t.+/compat_test.go:\\d+.*",
+	}, {
+		Wrapf(Wrapf(io.EOF, "error1"), "error2"),
+		"%v",
+		"error2: error1: EOF",
+	}, {
+		Wrap(Wrap(io.EOF, "error1"), "error2"),
+		"%v",
+		"error2 \\[error1 \\[EOF\\]\\]",
+	}, {
+		WithCause(io.EOF, "error%d", 3),
+		"%v",
+		"error3: EOF",
+	}, {
+		WithMessage(io.EOF, "error"),
+		"%s",
+		"error: EOF",
+	}, {
+		WithMessagef(WithMessage(io.EOF, "inner"), "outer %s", "error"),
+		"%+v",
+		"outer error: inner: EOF\n.*",
+	}, {
+		Errorf("%s", "error"),
+		"%+v",
+		"error\n.*" +
+			"\\.TestFormatWrap\n\t.+/compat_test.go:\\d+.*",
+	}}
+
+	for i, tt := range tests {
+		testFormatRegexp(t, i, tt.error, tt.format, tt.want)
+	}
+}
+
+func TestCause(t *testing.T) {
+	x := New("error")
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{nil, nil},
+		{io.EOF, io.EOF},
+		{x, x}, // an error without cause
+		{Wrap(io.EOF, "ignored"), io.EOF},
+		{Wrap(Wrapf(io.EOF, "a"), "b"), io.EOF},
+		{WithMessage(x, "whoops"), x},
+		{WithStack(io.EOF), io.EOF},
+		{WithCause(WithStack(io.EOF), "c"), io.EOF},
+	}
+
+	for i, tt := range tests {
+		if got := Cause(tt.err); got != tt.want { //nolint:errorlint
+			t.Errorf("test %d: got %#v, want %#v", i+1, got, tt.want)
+		}
+	}
+
+	if Cause1(Wrap(Wrapf(io.EOF, "a"), "b")) == io.EOF { //nolint:errorlint
+		t.Error("Cause1 should unwrap one level only")
+	}
+	if Cause1(io.EOF) != nil || Cause1(x) != nil {
+		t.Error("Cause1 should return nil for an error without cause")
+	}
+}
+
+func TestNilWrappers(t *testing.T) {
+	for i, err := range []error{
+		WithCause(nil, "x"),
+		Wrapf(nil, "x"),
+		WithMessage(nil, "x"),
+		WithMessagef(nil, "x"),
+	} {
+		if err != nil {
+			t.Errorf("test %d: expecting nil, but got %v", i+1, err)
+		}
+	}
+}
+
+func TestStackTracer(t *testing.T) {
+	var st StackTracer
+	var ok bool
+	if st, ok = Wrapf(io.EOF, "x").(StackTracer); !ok {
+		t.Fatal("expecting Wrapf returns a StackTracer")
+	}
+	if len(st.StackTrace()) == 0 {
+		t.Fatal("expecting a stack trace")
+	}
+	testFormatRegexp(t, 0, st.StackTrace()[0], "%+v", ".+\\.TestStackTracer\n\t.+/compat_test.go:\\d+")
+
+	if st, ok = WithMessage(io.EOF, "x").(StackTracer); !ok || st.StackTrace() != nil {
+		t.Fatal("expecting WithMessage records no stack")
+	}
+}
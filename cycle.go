@@ -0,0 +1,105 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"reflect"
+)
+
+// cycleMarker is printed in place of an error which has been printed
+// by one of its ancestors already, i.e. the error tree is cyclic.
+const cycleMarker = "<cycle>"
+
+// self returns the container itself. It gives a *WithStackInfo and its
+// embedded causes2 the same identity.
+func (w *causes2) self() *causes2 { return w }
+
+// nodeOf returns the identity of err in an error tree.
+//
+// Our error objects are identified by their causes2 container, other
+// errors by their pointer values. The others (value types) cannot be
+// part of a cycle and nil is returned for them.
+func nodeOf(err error) interface{} { //nolint:revive
+	if x, ok := err.(interface{ self() *causes2 }); ok {
+		return x.self()
+	}
+	if err != nil && reflect.TypeOf(err).Kind() == reflect.Ptr {
+		return err
+	}
+	return nil
+}
+
+type visitKey struct {
+	node   interface{} //nolint:revive
+	target interface{} //nolint:revive
+}
+
+// visitedSet records the nodes visited by a traversal, so that a
+// cyclic error tree can be processed without overflowing the stack.
+//
+// A node is recorded along with the target being searched, since
+// Is may test the same node against different targets in one pass.
+//
+// A nil set is ready to use, it is allocated by enter when the first
+// node which may have inner errors is entered, so that a traversal of
+// a flat error allocates nothing.
+type visitedSet map[visitKey]struct{}
+
+// enter records err and returns true if it has not been visited
+// with target yet. The errors without inner errors are not recorded,
+// they cannot be a part of a cycle.
+func (v *visitedSet) enter(err, target error) bool {
+	node := nodeOf(err)
+	if node == nil || !mayWrap(err) {
+		return true
+	}
+	key := visitKey{node, nodeOf(target)}
+	if _, ok := (*v)[key]; ok {
+		return false
+	}
+	if *v == nil {
+		*v = make(visitedSet)
+	}
+	(*v)[key] = struct{}{}
+	return true
+}
+
+// mayWrap reports whether err may have inner errors, see Children.
+func mayWrap(err error) bool {
+	switch err.(type) {
+	case interface{ self() *causes2 }, interface{ unwrapAll() []error },
+		interface{ Unwrap() []error }, interface{ Unwrap() error }, causer:
+		return true
+	}
+	return false
+}
+
+// leave removes err from the set, it is used by the traversals which
+// track the nodes on the current path only.
+func (v visitedSet) leave(err, target error) {
+	if node := nodeOf(err); node != nil {
+		delete(v, visitKey{node, nodeOf(target)})
+	}
+}
+
+// wouldCycle tests whether attaching err into w makes a cycle, that
+// is, w can be reached from err. An error without inner errors is not
+// walked, nor the leaves under err, and the walk stops at w.
+func (w *causes2) wouldCycle(err error) bool {
+	return mayWrap(err) && reaches(err, w, nil)
+}
+
+func reaches(from error, node *causes2, visited visitedSet) bool {
+	if from == nil || isNil(reflect.ValueOf(from)) || !visited.enter(from, nil) {
+		return false
+	}
+	if nodeOf(from) == interface{}(node) { //nolint:revive
+		return true
+	}
+	for _, e := range Children(from) {
+		if mayWrap(e) && reaches(e, node, visited) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestAttachCycle(t *testing.T) {
+	a := New("a")
+	b := New("b")
+	a.Attach(b)
+	b.Attach(a)
+	if len(Causes(b)) != 0 {
+		t.Fatalf("b.Attach(a) should be denied, but b = %v", b)
+	}
+
+	c := New("c").WithErrors(fmt.Errorf("wrapped: %w", a))
+	b.Attach(c)
+	_ = b.WithErrors(a).WithCause(c).WithData(a)
+	if len(Causes(b)) != 0 {
+		t.Fatalf("attaching an error holding b into b should be denied, but b = %v", b)
+	}
+
+	cc := &causes2{msg: "cc"}
+	dd := &causes2{msg: "dd"}
+	cc.Attach(dd)
+	dd.Attach(cc)
+	_ = dd.WithErrors(cc)
+	if len(dd.Causers) != 0 {
+		t.Fatalf("dd.Attach(cc) should be denied, but dd = %v", dd)
+	}
+}
+
+func TestCyclicTree(t *testing.T) {
+	a := New("a").WithCode(Internal)
+	b := New("b").WithErrors(io.EOF)
+	a.Attach(b)
+	// make a malformed tree by hand
+	b.(*WithStackInfo).Causers = append(b.(*WithStackInfo).Causers, a)
+
+	if s := a.Error(); !strings.Contains(s, cycleMarker) {
+		t.Fatalf("expecting cycle marker in %q", s)
+	}
+	if s := fmt.Sprintf("%+v", a); !strings.Contains(s, cycleMarker) {
+		t.Fatalf("expecting cycle marker in %q", s)
+	}
+	t.Logf("%v", a)
+
+	if !Is(a, io.EOF) || !Is(b, Internal) || Is(a, io.ErrShortWrite) {
+		t.Fatal("Is() on cyclic tree failed")
+	}
+	if !Iss(b, io.ErrShortWrite, Internal) || Iss(b, io.ErrShortWrite) {
+		t.Fatal("Iss() on cyclic tree failed")
+	}
+	if IsStd(a, io.ErrShortWrite) || TypeIs(a, &divisionErr{}) {
+		t.Fatal("IsStd()/TypeIs() on cyclic tree failed")
+	}
+	var de *divisionErr
+	if As(a, &de) {
+		t.Fatal("As() on cyclic tree failed")
+	}
+
+	var count int
+	_ = Walk(a, func(e error, depth int, path []int, parent error) error {
+		count++
+		return nil
+	})
+	if count != 4 { // a, b, EOF, Internal
+		t.Fatalf("Walk() on cyclic tree visits %d errors", count)
+	}
+}
+
+type divisionErr struct{}
+
+func (e *divisionErr) Error() string { return "division error" }
+
+// countingErr counts the calls of Unwrap.
+type countingErr struct {
+	err   error
+	calls int
+}
+
+func (e *countingErr) Error() string { return "counting: " + e.err.Error() }
+func (e *countingErr) Unwrap() error { e.calls++; return e.err }
+
+func TestWouldCycleStops(t *testing.T) {
+	a := New("a")
+	rest := &countingErr{err: io.EOF}
+	holder := New("holder").WithErrors(a, rest)
+	rest.calls = 0
+	if !a.(*WithStackInfo).wouldCycle(holder) {
+		t.Fatal("expecting a cycle")
+	}
+	if rest.calls != 0 {
+		t.Fatalf("the walk should stop at the receiver, %d calls", rest.calls)
+	}
+	if a.(*WithStackInfo).wouldCycle(io.EOF) {
+		t.Fatal("a leaf cannot make a cycle")
+	}
+}
+
+func TestVisitedSetLazy(t *testing.T) {
+	var code Code
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = IsStd(io.EOF, io.ErrUnexpectedEOF)
+		_ = TypeIs(io.EOF, io.ErrUnexpectedEOF)
+		_ = As(io.EOF, &code)
+	})
+	if allocs != 0 {
+		t.Fatalf("testing a flat error should not allocate: %v allocs", allocs)
+	}
+
+	var v visitedSet
+	if !v.enter(io.EOF, nil) || v != nil {
+		t.Fatal("a leaf error should not be recorded")
+	}
+	err := New("x")
+	if !v.enter(err, nil) || v.enter(err, nil) {
+		t.Fatal("a container should be recorded once")
+	}
+}
@@ -29,14 +29,14 @@ type Error interface {
 	// Causes simply returns the wrapped inner errors.
 	// It doesn't consider an wrapped Code entity is an inner error too.
 	// So if you wanna to extract any inner error objects, use
-	// errors.Unwrap for instead. The errors.Unwrap could extract all
-	// of them one by one:
+	// errors.Walk for instead. The errors.Walk visits all of them,
+	// including the nested ones:
 	//
 	//      var err = errors.New("hello").WithErrors(io.EOF, io.ShortBuffers)
-	//      var e error = err
-	//      for e != nil {
-	//          e = errors.Unwrap(err)
-	//      }
+	//      _ = errors.Walk(err, func(e error, depth int, path []int, parent error) error {
+	//          // process e...
+	//          return nil
+	//      })
 	//
 	Causes() []error
 }
@@ -71,12 +71,11 @@ type Buildable interface {
 	//      var errs []error
 	//      errors.As(err, &errs)
 	//
-	// Or, use Unwrap() for its:
+	// Or, use Walk() to visit the whole error tree:
 	//
-	//      var e error = err
-	//      for e != nil {
-	//          e = errors.Unwrap(err)
-	//      }
+	//      _ = errors.Walk(err, func(e error, depth int, path []int, parent error) error {
+	//          return nil
+	//      })
 	//
 	// WithErrors attach child errors into an error container.
 	// For a container which has IsEmpty() interface, it would not be
@@ -85,10 +84,12 @@ type Buildable interface {
 	WithErrors(errs ...error) Buildable
 	// WithData appends errs if the general object is a error object.
 	//
-	// StackTrace of errs will be copied to callee so that you can get a
-	// trace output nearer by the last error.
+	// The errors in errs keep their own stacks, %+v prints them layer
+	// by layer.
 	//
 	// defer-recover block typically is a better place of WithData().
+	// Recover and FromPanic make the error from a panic for the common
+	// case.
 	//
 	// For example:
 	//
@@ -96,7 +97,7 @@ type Buildable interface {
 	//      if e := recover(); e != nil {
 	//        err = errors.New("[recovered] copyTo unsatisfied ([%v] %v -> [%v] %v), causes: %v",
 	//          c.indirectType(from.Type()), from, c.indirectType(to.Type()), to, e).
-	//          WithData(e)                 // StackTrace of e -> err
+	//          WithData(e)                 // e is attached as a cause if it is an error
 	//        n := log.CalcStackFrames(1)   // skip defer-recover frame at first
 	//        log.Skip(n).Errorf("%v", err) // skip go-lib frames and defer-recover frame, back to the point throwing panic
 	//      }
@@ -163,12 +164,11 @@ type causer interface {
 //	errors.As(err, &errs)
 //	errs = errors.Causes(err)
 //
-// You may extract the inner errors one by one:
+// You may visit all the nested errors with Walk:
 //
-//	var e error = err
-//	for e != nil {
-//	    e = errors.Unwrap(err)
-//	}
+//	_ = errors.Walk(err, func(e error, depth int, path []int, parent error) error {
+//	    return nil
+//	})
 type causers interface {
 	// Causes _
 	Causes() []error
@@ -0,0 +1,56 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build !go1.13
+// +build !go1.13
+
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// errorf formats the message and extracts the errors given to %w
+// verbs, which are not supported by fmt.Errorf before go1.13.
+//
+// Each %w is formatted as %v. Explicit argument indexes are not
+// supported.
+func errorf(format string, args ...interface{}) (msg string, wrapped []error) { //nolint:revive
+	var sb strings.Builder
+	argNum := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		_ = sb.WriteByte(c)
+		if c != '%' {
+			continue
+		}
+		// skip flags, width and precision
+		for i+1 < len(format) && strings.IndexByte("+-# 0123456789.*", format[i+1]) >= 0 {
+			if format[i+1] == '*' {
+				argNum++
+			}
+			i++
+			_ = sb.WriteByte(format[i])
+		}
+		if i+1 >= len(format) {
+			break
+		}
+		i++
+		verb := format[i]
+		switch verb {
+		case '%':
+		case 'w':
+			if argNum < len(args) {
+				if e, ok := args[argNum].(error); ok && e != nil {
+					wrapped = append(wrapped, e)
+				}
+			}
+			verb = 'v'
+			argNum++
+		default:
+			argNum++
+		}
+		_ = sb.WriteByte(verb)
+	}
+	return fmt.Sprintf(sb.String(), args...), wrapped
+}
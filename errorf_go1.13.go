@@ -0,0 +1,17 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"fmt"
+)
+
+// errorf formats the message by fmt.Errorf and extracts the errors
+// wrapped by %w verbs.
+func errorf(format string, args ...interface{}) (msg string, wrapped []error) { //nolint:revive
+	e := fmt.Errorf(format, args...) //nolint:goerr113
+	return e.Error(), unwrapErrors(e)
+}
@@ -39,7 +39,9 @@ func New(args ...interface{}) Error { //nolint:revive
 		return s.Build()
 	}
 
-	return &WithStackInfo{Stack: callers(1)}
+	w := &WithStackInfo{}
+	w.Stack, w.omitted = callers(1, stackOpts{})
+	return w
 }
 
 // NewLite returns a simple message error object via stdlib (errors.New).
@@ -70,6 +72,30 @@ func NewLite(args ...interface{}) error { //nolint:revive
 	return UnsupportedOperation // errors.ErrUnsupported
 }
 
+// Errorf formats according to a format specifier and returns an
+// error object with the Stack trace at the point where it was called.
+//
+// Errorf is a drop-in replacement of fmt.Errorf. The message is
+// rendered identically to fmt.Errorf, and the errors given to the %w
+// verbs are wrapped as inner errors so that Is, As and Causes work:
+//
+//	err := errors.Errorf("read %q failed: %w", name, io.EOF)
+//	println(errors.Is(err, io.EOF)) // true
+//
+// Multiple %w verbs are supported as fmt.Errorf of go1.20+ does.
+func Errorf(format string, args ...interface{}) Error { //nolint:revive
+	msg, wrapped := errorf(format, args...)
+	w := &WithStackInfo{
+		causes2: causes2{
+			Causers:   wrapped,
+			msg:       msg,
+			msgCauses: len(wrapped),
+		},
+	}
+	w.Stack, w.omitted = callers(1, stackOpts{}.wrapping(wrapped...))
+	return w
+}
+
 // Opt _
 type Opt func(s *builder)
 
@@ -87,6 +113,36 @@ func WithErrors(errs ...error) Opt {
 	}
 }
 
+// WithStackDepth specifies the maximum number of stack frames
+// recorded by New, see SetStackDepth:
+//
+//	err := errors.New(errors.WithStackDepth(errors.UnlimitedStackDepth), errors.WithErrors(io.EOF))
+func WithStackDepth(depth int) Opt {
+	return func(s *builder) {
+		s.WithStackDepth(depth)
+	}
+}
+
+// WithCapturePolicy specifies how the stack is recorded by New, see
+// SetCapturePolicy:
+//
+//	err := errors.New(errors.WithCapturePolicy(errors.CaptureNone), errors.WithErrors(io.EOF))
+func WithCapturePolicy(p CapturePolicy) Opt {
+	return func(s *builder) {
+		s.WithCapturePolicy(p)
+	}
+}
+
+// WithFrameFilter specifies the filter to elide the frames from the
+// stack printed by %+v, see WithStackInfo.WithFrameFilter:
+//
+//	err := errors.New(errors.WithFrameFilter(&errors.FrameFilter{}), errors.WithErrors(io.EOF))
+func WithFrameFilter(ff *FrameFilter) Opt {
+	return func(s *builder) {
+		s.WithFrameFilter(ff)
+	}
+}
+
 // Skip sets how many frames will be ignored while we are extracting
 // the stacktrace info.
 // Skip starts a builder with fluent API style, so you could continue
@@ -134,6 +190,15 @@ type Builder interface {
 	WithMessage(message string, args ...interface{}) Builder //nolint:revive
 	// WithCode specifies an error code.
 	WithCode(code Code) Builder
+	// WithStackDepth specifies the maximum number of stack frames
+	// recorded, see SetStackDepth.
+	WithStackDepth(depth int) Builder
+	// WithCapturePolicy specifies how the stack is recorded, see
+	// SetCapturePolicy.
+	WithCapturePolicy(p CapturePolicy) Builder
+	// WithFrameFilter specifies the filter to elide the frames from
+	// the printed stack, see SetFrameFilter.
+	WithFrameFilter(ff *FrameFilter) Builder
 
 	// Build builds the final error object (with Buildable interface
 	// bound)
@@ -146,6 +211,8 @@ type Builder interface {
 
 type builder struct {
 	skip        int
+	stack       stackOpts
+	filter      *FrameFilter
 	causes2     causes2
 	sites       []interface{} //nolint:revive
 	taggedSites TaggedData
@@ -158,6 +225,27 @@ func (s *builder) WithSkip(skip int) Builder {
 	return s
 }
 
+// WithStackDepth specifies the maximum number of stack frames
+// recorded, 0 for the package setting, see SetStackDepth.
+func (s *builder) WithStackDepth(depth int) Builder {
+	s.stack.depth = depth
+	return s
+}
+
+// WithCapturePolicy specifies how the stack is recorded,
+// CaptureDefault for the package policy, see SetCapturePolicy.
+func (s *builder) WithCapturePolicy(p CapturePolicy) Builder {
+	s.stack.policy = p
+	return s
+}
+
+// WithFrameFilter specifies the filter to elide the frames from the
+// printed stack, nil for the package filter, see SetFrameFilter.
+func (s *builder) WithFrameFilter(ff *FrameFilter) Builder {
+	s.filter = ff
+	return s
+}
+
 // WithCode specifies an error code.
 func (s *builder) WithCode(code Code) Builder {
 	s.causes2.Code = code
@@ -189,7 +277,9 @@ func (s *builder) WithErrors(errs ...error) Builder {
 }
 
 // WithData appends errs if the general object is a error object.
-// It can be used in defer-recover block typically. For example:
+// It can be used in defer-recover block typically, Recover and
+// FromPanic make the error from a panic for the common case. For
+// example:
 //
 //	defer func() {
 //	  if e := recover(); e != nil {
@@ -225,9 +315,7 @@ func (s *builder) WithCause(cause error) Builder {
 
 // Build builds the final error object (with *WithStackInfo type wrapped)
 func (s *builder) Build() Error {
-	w := &WithStackInfo{
-		causes2: s.causes2,
-		Stack:   callers(s.skip),
-	}
+	w := &WithStackInfo{causes2: s.causes2, filter: s.filter}
+	w.Stack, w.omitted = callers(s.skip, s.stack.wrapping(s.causes2.Causers...))
 	return w
 }
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"testing"
@@ -140,3 +141,25 @@ func TestIsDeep(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorf(t *testing.T) {
+	err := Errorf("read %q failed: %w", "a.txt", io.EOF)
+	if expect := fmt.Errorf("read %q failed: %w", "a.txt", io.EOF).Error(); err.Error() != expect {
+		t.Fatalf("expecting %q, but got %q", expect, err.Error())
+	}
+	if !Is(err, io.EOF) {
+		t.Fatal("expecting err is io.EOF")
+	}
+	if causes := Causes(err); len(causes) != 1 || causes[0] != io.EOF {
+		t.Fatalf("expecting io.EOF in Causes, but got %v", causes)
+	}
+	if st := err.(*WithStackInfo).Stack; st == nil || len(*st) == 0 {
+		t.Fatal("expecting a stack trace")
+	}
+	t.Logf("%+v", err)
+
+	err = Errorf("no wrapping %d", 1)
+	if err.Error() != "no wrapping 1" || len(Causes(err)) != 0 {
+		t.Fatalf("unexpected %q, causes = %v", err, Causes(err))
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// FrameFilter elides the uninteresting frames, such as the runtime,
+// the testing and the middleware frames, from the printed stacks.
+//
+// A frame is dropped if it matches any of the rules:
+//
+//	errors.SetFrameFilter(&errors.FrameFilter{
+//	    Packages:  []string{"runtime", "testing", "github.com/me/app/middleware"},
+//	    Functions: []*regexp.Regexp{regexp.MustCompile(`\.ServeHTTP$`)},
+//	    Files:     []string{"*_gen.go"},
+//	})
+//
+// The filter applies to the formatting by %+v (see Stack.Format and
+// StackTrace.Format) only, Frames and the JSON encoding return all
+// frames.
+type FrameFilter struct {
+	// Packages are the package paths, a frame in one of them or in
+	// their subpackages is dropped.
+	Packages []string
+	// Functions are matched against the function names with the
+	// package path, such as "net/http.HandlerFunc.ServeHTTP".
+	Functions []*regexp.Regexp
+	// Files are the path.Match patterns, matched against the full
+	// path and the base name of the source file.
+	Files []string
+}
+
+// DefaultFrameFilter returns the default filter of the package, it
+// drops the frames of the runtime and testing packages.
+func DefaultFrameFilter() *FrameFilter {
+	return &FrameFilter{Packages: []string{"runtime", "testing"}}
+}
+
+// Match reports whether f should be dropped. A nil filter matches
+// nothing.
+func (ff *FrameFilter) Match(f ResolvedFrame) bool {
+	if ff == nil {
+		return false
+	}
+	for _, p := range ff.Packages {
+		if f.Package == p || strings.HasPrefix(f.Package, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	for _, re := range ff.Functions {
+		if re != nil && re.MatchString(f.Function) {
+			return true
+		}
+	}
+	for _, pattern := range ff.Files {
+		if ok, _ := path.Match(pattern, f.File); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(f.File)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the frames not matched by ff.
+func (ff *FrameFilter) Filter(frames []ResolvedFrame) []ResolvedFrame {
+	if ff == nil {
+		return frames
+	}
+	kept := make([]ResolvedFrame, 0, len(frames))
+	for _, f := range frames {
+		if !ff.Match(f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+var frameFilter atomic.Value
+
+func init() { //nolint:gochecknoinits
+	frameFilter.Store(DefaultFrameFilter())
+}
+
+// SetFrameFilter sets the package filter to elide the frames from
+// the printed stacks. An empty FrameFilter prints all frames, and nil
+// restores DefaultFrameFilter.
+//
+// It can be overridden by an error, see WithStackInfo.WithFrameFilter
+// and Builder.WithFrameFilter.
+func SetFrameFilter(ff *FrameFilter) {
+	if ff == nil {
+		ff = DefaultFrameFilter()
+	}
+	frameFilter.Store(ff)
+}
+
+// GetFrameFilter returns the package filter, see SetFrameFilter.
+func GetFrameFilter() *FrameFilter {
+	return frameFilter.Load().(*FrameFilter) //nolint:forcetypeassert
+}
@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFrameFilter(t *testing.T) {
+	ff := &FrameFilter{
+		Packages:  []string{"net/http", "runtime"},
+		Functions: []*regexp.Regexp{regexp.MustCompile(`\.ServeHTTP$`)},
+		Files:     []string{"*_gen.go", "/src/vendor/*"},
+	}
+	for _, c := range []struct {
+		f    ResolvedFrame
+		want bool
+	}{
+		{ResolvedFrame{Package: "runtime", Function: "runtime.goexit"}, true},
+		{ResolvedFrame{Package: "runtime/debug", Function: "runtime/debug.Stack"}, true},
+		{ResolvedFrame{Package: "runtimex", Function: "runtimex.F"}, false},
+		{ResolvedFrame{Package: "net/http/httptest", Function: "net/http/httptest.F"}, true},
+		{ResolvedFrame{Package: "app", Function: "app.(*mw).ServeHTTP"}, true},
+		{ResolvedFrame{Package: "app", Function: "app.Handle", File: "/src/app/api_gen.go"}, true},
+		{ResolvedFrame{Package: "app", Function: "app.Handle", File: "/src/vendor/x.go"}, true},
+		{ResolvedFrame{Package: "app", Function: "app.Handle", File: "/src/app/api.go"}, false},
+	} {
+		if got := ff.Match(c.f); got != c.want {
+			t.Errorf("Match(%v) = %v, want %v", c.f.Function, got, c.want)
+		}
+	}
+
+	var none *FrameFilter
+	if none.Match(ResolvedFrame{Package: "runtime"}) || len(none.Filter([]ResolvedFrame{{}})) != 1 {
+		t.Fatal("a nil filter should match nothing")
+	}
+}
+
+func TestFrameFilterFormat(t *testing.T) {
+	defer SetFrameFilter(DefaultFrameFilter())
+
+	err := New("filtered")
+	text := fmt.Sprintf("%+v", err)
+	if strings.Contains(text, "testing.tRunner") || strings.Contains(text, "runtime.goexit") {
+		t.Fatalf("runtime and testing frames should be elided:\n%s", text)
+	}
+	if !strings.Contains(text, "TestFrameFilterFormat") {
+		t.Fatalf("the test frame is missing:\n%s", text)
+	}
+	if text := fmt.Sprintf("%+v", err.(*WithStackInfo).StackTrace()); strings.Contains(text, "testing.tRunner") {
+		t.Fatalf("StackTrace.Format should elide the frames:\n%s", text)
+	}
+
+	// per error
+	all := New(WithFrameFilter(&FrameFilter{}))
+	if text := fmt.Sprintf("%+v", all); !strings.Contains(text, "testing.tRunner") {
+		t.Fatalf("an empty filter should print all frames:\n%s", text)
+	}
+	_ = err.(*WithStackInfo).WithFrameFilter(&FrameFilter{Packages: []string{"gopkg.in/hedzr/errors.v3"}})
+	if text := fmt.Sprintf("%+v", err); strings.Contains(text, "TestFrameFilterFormat") || !strings.Contains(text, "testing.tRunner") {
+		t.Fatalf("the error filter should override the package one:\n%s", text)
+	}
+
+	// package
+	SetFrameFilter(&FrameFilter{})
+	if text := fmt.Sprintf("%+v", New("all")); !strings.Contains(text, "runtime.goexit") {
+		t.Fatalf("an empty package filter should print all frames:\n%s", text)
+	}
+	SetFrameFilter(nil)
+	if text := fmt.Sprintf("%+v", New("default")); strings.Contains(text, "runtime.goexit") ||
+		len(GetFrameFilter().Packages) != len(DefaultFrameFilter().Packages) {
+		t.Fatalf("a nil package filter should restore the default one:\n%s", text)
+	}
+	if len(err.(*WithStackInfo).Frames()) == 0 {
+		t.Fatal("Frames should not be filtered")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+// AsType finds the first error in err's tree that matches the type E,
+// and if one is found, returns it and true.
+//
+// AsType is like As but without a target variable:
+//
+//	if pe, ok := errors.AsType[*fs.PathError](err); ok {
+//	    println(pe.Path)
+//	}
+//
+// AsType, Find and FindAll match the errors of the tree in the order of
+// Walk by the same rule, see asNode. Unlike As, AsType[Code] finds the
+// Code of an error only if it is not OK, since an OK Code is not a
+// node of the tree.
+func AsType[E error](err error) (E, bool) {
+	return Find[E](err, nil)
+}
+
+// asNode reports whether the node e of a tree is an E, by the type
+// assertion or by the As method of e.
+//
+// The As method of our errors is not used, since it searches the
+// inner errors too, which are the other nodes of the tree.
+func asNode[E error](e error) (x E, ok bool) {
+	if x, ok = e.(E); ok {
+		return
+	}
+	if _, ours := e.(interface{ self() *causes2 }); ours {
+		return
+	}
+	if a, yes := e.(interface{ As(interface{}) bool }); yes && a.As(&x) { //nolint:revive
+		return x, true
+	}
+	return x, false
+}
+
+// Find returns the first error in err's tree which is an E and
+// satisfies pred. A nil pred matches any E. The errors are matched as
+// AsType does.
+//
+// The tree is walked in the order of Walk.
+func Find[E error](err error, pred func(E) bool) (found E, ok bool) {
+	_ = Walk(err, func(e error, depth int, path []int, parent error) error {
+		if x, yes := asNode[E](e); yes && (pred == nil || pred(x)) {
+			found, ok = x, true
+			return SkipAll
+		}
+		return nil
+	})
+	return
+}
+
+// FindAll collects every error in err's tree which is an E, in the
+// order of Walk. The errors are matched as AsType does.
+//
+//	for _, pe := range errors.FindAll[*fs.PathError](err) {
+//	    println(pe.Path)
+//	}
+func FindAll[E error](err error) (all []E) {
+	_ = Walk(err, func(e error, depth int, path []int, parent error) error {
+		if x, ok := asNode[E](e); ok {
+			all = append(all, x)
+		}
+		return nil
+	})
+	return
+}
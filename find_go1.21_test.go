@@ -0,0 +1,90 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.21
+// +build go1.21
+
+package errors_test
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	v3 "gopkg.in/hedzr/errors.v3"
+)
+
+func TestAsType(t *testing.T) {
+	_, e1 := os.Open("/not-exist/1")
+	err := v3.New("outer").WithErrors(io.EOF, v3.New("inner").WithErrors(e1))
+
+	pe, ok := v3.AsType[*fs.PathError](err)
+	if !ok || pe.Path != "/not-exist/1" {
+		t.Fatalf("AsType[*fs.PathError] failed, pe = %v", pe)
+	}
+	if _, ok = v3.AsType[*DivisionError](err); ok {
+		t.Fatal("AsType[*DivisionError] should fail")
+	}
+	if _, ok = v3.AsType[*fs.PathError](nil); ok {
+		t.Fatal("AsType on nil error should fail")
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	_, e1 := os.Open("/not-exist/1")
+	_, e2 := os.Open("/not-exist/2")
+	_, e3 := os.Open("/not-exist/3")
+	err := v3.New("outer").WithErrors(e1, v3.New("inner").WithErrors(io.EOF, e2), e3).WithCode(v3.NotFound)
+
+	all := v3.FindAll[*fs.PathError](err)
+	if len(all) != 3 || all[0].Path != "/not-exist/1" || all[1].Path != "/not-exist/2" || all[2].Path != "/not-exist/3" {
+		t.Fatalf("FindAll[*fs.PathError] failed, all = %v", all)
+	}
+	if codes := v3.FindAll[v3.Code](err); len(codes) != 1 || codes[0] != v3.NotFound {
+		t.Fatalf("FindAll[Code] failed, codes = %v", codes)
+	}
+
+	pe, ok := v3.Find(err, func(pe *fs.PathError) bool { return pe.Path == "/not-exist/2" })
+	if !ok || pe != e2 {
+		t.Fatalf("Find failed, pe = %v", pe)
+	}
+
+	if e := v3.FindFunc(err, func(e error) bool { return e == io.EOF }); e != io.EOF {
+		t.Fatalf("FindFunc failed, e = %v", e)
+	}
+	if e := v3.FindFunc(err, func(e error) bool { return false }); e != nil {
+		t.Fatalf("FindFunc should return nil, but got %v", e)
+	}
+}
+
+// asPathError is a foreign error which converts itself by As.
+type asPathError struct{ path string }
+
+func (e *asPathError) Error() string { return "as " + e.path }
+func (e *asPathError) As(target interface{}) bool { //nolint:revive
+	if pe, ok := target.(**fs.PathError); ok {
+		*pe = &fs.PathError{Op: "as", Path: e.path}
+		return true
+	}
+	return false
+}
+
+func TestAsTypeFindAllAgree(t *testing.T) {
+	err := v3.New("outer").WithErrors(&asPathError{"/a"}, v3.New("inner").WithErrors(io.EOF))
+
+	pe, ok := v3.AsType[*fs.PathError](err)
+	all := v3.FindAll[*fs.PathError](err)
+	if !ok || len(all) != 1 || pe.Path != "/a" || all[0].Path != "/a" {
+		t.Fatalf("AsType and FindAll disagree: %v, %v", pe, all)
+	}
+
+	if c, ok := v3.AsType[v3.Code](err); ok {
+		t.Fatalf("an error without Code should not match Code, got %v", c)
+	}
+	if codes := v3.FindAll[v3.Code](err); len(codes) != 0 {
+		t.Fatalf("FindAll[Code] = %v", codes)
+	}
+	if c, ok := v3.AsType[v3.Code](v3.New("x").WithErrors(err).WithCode(v3.NotFound)); !ok || c != v3.NotFound {
+		t.Fatalf("AsType[Code] = %v, %v", c, ok)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultFrameCacheSize is the default maximum number of program
+// counters kept in the frame cache.
+const DefaultFrameCacheSize = 4096
+
+// FrameCacheStats are the metrics of the frame cache, see
+// GetFrameCacheStats.
+type FrameCacheStats struct {
+	Hits      uint64 // the lookups found in the cache
+	Misses    uint64 // the lookups resolved by the runtime
+	Evictions uint64 // the entries removed to keep the size limit
+	Size      int    // the number of program counters cached
+	Limit     int    // the maximum number of program counters cached, 0 if disabled
+}
+
+type frameKey struct {
+	pc            uintptr
+	afterSigpanic bool
+}
+
+// pcCache maps the program counters to the resolved frames, it is
+// shared by all Frame, StackTrace and Stack formatting so that a
+// repeated error is formatted cheaply.
+type pcCache struct {
+	sync.RWMutex
+	frames map[frameKey]resolvedPC
+	limit  int // 0 if disabled
+
+	hits, misses, evictions uint64
+}
+
+var frameCache = pcCache{frames: make(map[frameKey]resolvedPC), limit: DefaultFrameCacheSize}
+
+// get returns the frames of k, which are resolved by resolve and
+// cached if not found.
+func (c *pcCache) get(k frameKey, resolve func() resolvedPC) resolvedPC {
+	c.RLock()
+	r, ok := c.frames[k]
+	limit := c.limit
+	c.RUnlock()
+	if ok {
+		atomic.AddUint64(&c.hits, 1)
+		return r
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+	r = resolve()
+	if limit <= 0 {
+		return r
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if _, ok = c.frames[k]; !ok && c.limit > 0 {
+		c.evict(len(c.frames) + 1 - c.limit)
+		c.frames[k] = r
+	}
+	return r
+}
+
+// evict removes n entries at random, the lock must be held.
+func (c *pcCache) evict(n int) {
+	for k := range c.frames {
+		if n <= 0 {
+			break
+		}
+		delete(c.frames, k)
+		atomic.AddUint64(&c.evictions, 1)
+		n--
+	}
+}
+
+// SetFrameCacheSize sets the maximum number of program counters kept
+// in the frame cache, the entries beyond it are evicted. A size of 0
+// or less disables the cache.
+//
+// The frame cache maps the program counters of the recorded stacks to
+// their resolved frames, so that formatting an error repeatedly, such
+// as logging it at several layers, resolves each frame once.
+func SetFrameCacheSize(size int) {
+	frameCache.Lock()
+	defer frameCache.Unlock()
+	if size < 0 {
+		size = 0
+	}
+	frameCache.limit = size
+	frameCache.evict(len(frameCache.frames) - size)
+}
+
+// ResetFrameCache removes all entries from the frame cache, and
+// resets its metrics.
+func ResetFrameCache() {
+	frameCache.Lock()
+	defer frameCache.Unlock()
+	frameCache.frames = make(map[frameKey]resolvedPC)
+	atomic.StoreUint64(&frameCache.hits, 0)
+	atomic.StoreUint64(&frameCache.misses, 0)
+	atomic.StoreUint64(&frameCache.evictions, 0)
+}
+
+// GetFrameCacheStats returns the metrics of the frame cache.
+func GetFrameCacheStats() FrameCacheStats {
+	frameCache.RLock()
+	defer frameCache.RUnlock()
+	return FrameCacheStats{
+		Hits:      atomic.LoadUint64(&frameCache.hits),
+		Misses:    atomic.LoadUint64(&frameCache.misses),
+		Evictions: atomic.LoadUint64(&frameCache.evictions),
+		Size:      len(frameCache.frames),
+		Limit:     frameCache.limit,
+	}
+}
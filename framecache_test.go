@@ -0,0 +1,85 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestFrameCache(t *testing.T) {
+	defer SetFrameCacheSize(DefaultFrameCacheSize)
+	ResetFrameCache()
+
+	err := New("cached")
+	first := fmt.Sprintf("%+v", err)
+	st := GetFrameCacheStats()
+	if st.Misses == 0 || st.Size == 0 || st.Limit != DefaultFrameCacheSize {
+		t.Fatalf("bad stats after the first formatting: %+v", st)
+	}
+
+	if second := fmt.Sprintf("%+v", err); second != first {
+		t.Fatalf("cached formatting differs:\n%s\n---\n%s", first, second)
+	}
+	st2 := GetFrameCacheStats()
+	if st2.Misses != st.Misses || st2.Hits == st.Hits {
+		t.Fatalf("expect hits only: %+v -> %+v", st, st2)
+	}
+
+	SetFrameCacheSize(1)
+	if st = GetFrameCacheStats(); st.Size != 1 || st.Evictions == 0 {
+		t.Fatalf("bad stats after shrinking: %+v", st)
+	}
+	_ = fmt.Sprintf("%+v", err)
+	if st = GetFrameCacheStats(); st.Size != 1 {
+		t.Fatalf("size over limit: %+v", st)
+	}
+
+	SetFrameCacheSize(0)
+	if second := fmt.Sprintf("%+v", err); second != first {
+		t.Fatalf("uncached formatting differs:\n%s\n---\n%s", first, second)
+	}
+	if st = GetFrameCacheStats(); st.Size != 0 || st.Limit != 0 {
+		t.Fatalf("bad stats of disabled cache: %+v", st)
+	}
+
+	ResetFrameCache()
+	if st = GetFrameCacheStats(); st.Hits != 0 || st.Misses != 0 || st.Evictions != 0 {
+		t.Fatalf("bad stats after reset: %+v", st)
+	}
+}
+
+func TestFrameCacheConcurrent(t *testing.T) {
+	defer SetFrameCacheSize(DefaultFrameCacheSize)
+	SetFrameCacheSize(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = fmt.Sprintf("%+v", recurse(j%5, func() error { return New("concurrent") }))
+			}
+		}()
+	}
+	wg.Wait()
+	if st := GetFrameCacheStats(); st.Size > 8 {
+		t.Fatalf("size over limit: %+v", st)
+	}
+}
+
+func BenchmarkFormatCached(b *testing.B) {
+	err := New("bench")
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", err)
+	}
+}
+
+func BenchmarkFormatUncached(b *testing.B) {
+	defer SetFrameCacheSize(DefaultFrameCacheSize)
+	SetFrameCacheSize(0)
+	err := New("bench")
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"fmt"
+)
+
+// Task is the handle of a goroutine started by Go.
+type Task struct {
+	done chan struct{}
+	err  error
+}
+
+// Go runs fn in a new goroutine, and returns a Task to wait for its
+// error:
+//
+//	t := errors.Go(func() error {
+//	    return s.sync(ctx)
+//	})
+//	...
+//	if err := t.Wait(); err != nil {
+//	    log.Printf("%+v", err)
+//	}
+//
+// A panic in fn is recovered as FromPanic does. The error returned
+// or recovered is a *WithStackInfo which records the stack of the
+// caller of Go, %+v prints it as the "Spawned from" trace after the
+// traces of the error, see WithStackInfo.SpawnedFrom. The error
+// returned by fn is wrapped rather than modified, so Is and As work
+// on it as usual.
+func Go(fn func() error) *Task {
+	t := &Task{done: make(chan struct{})}
+	spawned, _ := callers(1, stackOpts{})
+	go func() {
+		defer close(t.done)
+		defer func() {
+			if r := recover(); r != nil {
+				w := fromPanic(r)
+				w.spawned = spawned
+				t.err = w
+			}
+		}()
+		if err := fn(); err != nil {
+			w := &WithStackInfo{causes2: causes2{Causers: []error{err}, msgCauses: 1, wrapsCause: true}}
+			w.spawned = spawned
+			t.err = w
+		}
+	}()
+	return t
+}
+
+// Wait waits for the goroutine to finish, and returns its error.
+func (t *Task) Wait() error {
+	<-t.done
+	return t.err
+}
+
+// Done returns a channel which is closed when the goroutine finishes.
+func (t *Task) Done() <-chan struct{} { return t.done }
+
+// SpawnedFrom returns the stack of the caller of Go, if w is the
+// error of a goroutine started by Go.
+func (w *WithStackInfo) SpawnedFrom() *Stack { return w.spawned }
+
+// formatSpawned prints the stack of the caller of Go.
+func (w *WithStackInfo) formatSpawned(s fmt.State) {
+	if w.spawned == nil {
+		return
+	}
+	_, _ = fmt.Fprint(s, "\nSpawned from:")
+	for _, f := range w.frameFilter().Filter(w.spawned.Frames()) {
+		_, _ = fmt.Fprintf(s, "\n%+v", f)
+	}
+}
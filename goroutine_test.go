@@ -0,0 +1,53 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+//go:noinline
+func spawnTask(fn func() error) *Task { return Go(fn) }
+
+func TestGo(t *testing.T) {
+	if err := Go(func() error { return nil }).Wait(); err != nil {
+		t.Fatalf("expect nil: %v", err)
+	}
+
+	task := spawnTask(func() error { return Wrap(io.EOF, "sync") })
+	<-task.Done()
+	err := task.Wait()
+	if !Is(err, io.EOF) || err.Error() != "sync [EOF]" {
+		t.Fatalf("bad error: %+v", err)
+	}
+	text := fmt.Sprintf("%+v", err)
+	i, j := strings.Index(text, "Caused by: sync [EOF]"), strings.Index(text, "Spawned from:")
+	if i < 0 || j < i || !strings.Contains(text[j:], "spawnTask") || !strings.Contains(text[j:], "TestGo") {
+		t.Fatalf("expect the trace of the error, then the spawner's one:\n%s", text)
+	}
+	if s := err.(*WithStackInfo).SpawnedFrom(); s == nil || !strings.HasSuffix(s.Frames()[0].Function, ".spawnTask") {
+		t.Fatalf("bad spawner's stack: %v", s)
+	}
+
+	err = spawnTask(func() error {
+		var m map[string]int
+		m["x"] = 1
+		return nil
+	}).Wait()
+	if !Is(err, Internal) || !strings.HasPrefix(err.Error(), "panic: ") {
+		t.Fatalf("the panic should be recovered: %+v", err)
+	}
+	text = fmt.Sprintf("%+v", err)
+	if j := strings.Index(text, "Spawned from:"); j < 0 || !strings.Contains(text[:j], "TestGo.func") || !strings.Contains(text[j:], "spawnTask") {
+		t.Fatalf("expect the panic site, then the spawner's stack:\n%s", text)
+	}
+
+	// nested
+	err = Go(func() error {
+		return spawnTask(func() error { return io.ErrUnexpectedEOF }).Wait()
+	}).Wait()
+	if !Is(err, io.ErrUnexpectedEOF) || strings.Count(fmt.Sprintf("%+v", err), "Spawned from:") != 2 {
+		t.Fatalf("expect both spawners' stacks:\n%+v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// helperRegistry holds the helper functions and packages, whose frames
+// are skipped from the top of the recorded stacks.
+type helperRegistry struct {
+	sync.RWMutex
+	funcs    map[string]bool   // the function names, as reported by the runtime
+	packages []string          // the package paths
+	decided  map[frameKey]bool // whether a pc is in the helpers, reset by a registration
+	count    int32             // the number of helpers, read atomically
+}
+
+var helpers = helperRegistry{funcs: make(map[string]bool), decided: make(map[frameKey]bool)}
+
+// helperFrames is the number of the extra frames recorded for the
+// helpers on the top, a deeper nesting records the stack once more.
+const helperFrames = 8
+
+// Helper marks the calling function as a helper function, like
+// testing.T.Helper does. The stacks recorded by New, Wrap, Code.New,
+// the builder and the others start at the first frame outside the
+// helper functions, so that counting frames by WithSkip is not
+// needed:
+//
+//	func failf(format string, args ...interface{}) error {
+//	    errors.Helper()
+//	    return errors.New(format, args...) // recorded from the caller of failf
+//	}
+//
+// It can be called each time, only the first call registers.
+func Helper() {
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		return
+	}
+	if f := runtime.FuncForPC(pc); f != nil {
+		registerHelper(f.Name())
+	}
+}
+
+// RegisterHelperFunc marks fn as a helper function, see Helper. It is
+// for the functions which cannot call Helper, such as the ones of a
+// logging library. fn must be a function, or it is ignored.
+func RegisterHelperFunc(fn interface{}) { //nolint:revive
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		registerHelper(f.Name())
+	}
+}
+
+// RegisterHelperPackage marks all functions in the package pkgPath and
+// its subpackages as helper functions, see Helper:
+//
+//	errors.RegisterHelperPackage("github.com/me/app/internal/fail")
+func RegisterHelperPackage(pkgPath string) {
+	pkgPath = strings.TrimSuffix(pkgPath, "/")
+	if pkgPath == "" {
+		return
+	}
+	helpers.Lock()
+	defer helpers.Unlock()
+	for _, p := range helpers.packages {
+		if p == pkgPath {
+			return
+		}
+	}
+	helpers.packages = append(helpers.packages, pkgPath)
+	helpers.decided = make(map[frameKey]bool)
+	atomic.AddInt32(&helpers.count, 1)
+}
+
+func registerHelper(name string) {
+	helpers.RLock()
+	found := helpers.funcs[name]
+	helpers.RUnlock()
+	if found {
+		return
+	}
+
+	helpers.Lock()
+	defer helpers.Unlock()
+	if !helpers.funcs[name] {
+		helpers.funcs[name] = true
+		helpers.decided = make(map[frameKey]bool)
+		atomic.AddInt32(&helpers.count, 1)
+	}
+}
+
+// active reports whether any helper is registered.
+func (h *helperRegistry) active() bool {
+	return atomic.LoadInt32(&h.count) > 0
+}
+
+// skip returns the number of the leading pcs in the helpers. A pc
+// is in the helpers if all of its frames are, so a helper inlined into
+// its caller is kept.
+func (h *helperRegistry) skip(pcs []uintptr) (n int) {
+	walkPCs(pcs, func(k frameKey, r resolvedPC) bool {
+		if !h.contains(k, r.frames) {
+			return false
+		}
+		n++
+		return true
+	})
+	return
+}
+
+// contains reports whether frames, resolved from the pc identified by
+// k, are all in the helpers. The answer is cached by k.
+func (h *helperRegistry) contains(k frameKey, frames []ResolvedFrame) (yes bool) {
+	h.RLock()
+	yes, ok := h.decided[k]
+	h.RUnlock()
+	if ok {
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	yes = len(frames) > 0
+	for _, f := range frames {
+		if !h.match(f) {
+			yes = false
+			break
+		}
+	}
+	h.decided[k] = yes
+	return
+}
+
+func (h *helperRegistry) match(f ResolvedFrame) bool {
+	if h.funcs[f.Function] {
+		return true
+	}
+	for _, p := range h.packages {
+		if f.Package == p || strings.HasPrefix(f.Package, p+"/") {
+			return true
+		}
+	}
+	return false
+}
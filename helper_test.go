@@ -0,0 +1,84 @@
+package errors
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+//go:noinline
+func failNew(msg string) error {
+	Helper()
+	return New(msg)
+}
+
+//go:noinline
+func failTwice(msg string) error {
+	Helper()
+	return failNew(msg)
+}
+
+//go:noinline
+func failDeep(n int) error {
+	Helper()
+	if n == 0 {
+		return New("deep")
+	}
+	return failDeep(n - 1)
+}
+
+//go:noinline
+func failWrap(err error) error { return Wrap(err, "wrapped") }
+
+func resetHelpers() {
+	helpers.Lock()
+	defer helpers.Unlock()
+	helpers.funcs, helpers.packages, helpers.decided = make(map[string]bool), nil, make(map[frameKey]bool)
+	atomic.StoreInt32(&helpers.count, 0)
+}
+
+func TestHelper(t *testing.T) {
+	defer resetHelpers()
+	defer SetCapturePolicy(CaptureDefault)
+
+	top := func(err error) ResolvedFrame { return err.(*WithStackInfo).Frames()[0] }
+	isTest := func(err error) bool { return strings.HasSuffix(top(err).Function, ".TestHelper") }
+
+	if err := failNew("x"); !isTest(err) {
+		t.Fatalf("Helper: %v", top(err).Function)
+	}
+	if err := failTwice("x"); !isTest(err) {
+		t.Fatalf("nested helpers: %v", top(err).Function)
+	}
+
+	if err := failWrap(New("x")); isTest(err) {
+		t.Fatal("not registered yet")
+	}
+	RegisterHelperFunc(failWrap)
+	RegisterHelperFunc("not a function")
+	if err := failWrap(New("x")); !isTest(err) {
+		t.Fatalf("RegisterHelperFunc: %v", top(err).Function)
+	}
+
+	SetCapturePolicy(CaptureCaller)
+	if err := failNew("x"); !isTest(err) || len(*err.(*WithStackInfo).Stack) != 1 {
+		t.Fatalf("CaptureCaller: %+v", err)
+	}
+	SetCapturePolicy(CaptureDefault)
+
+	if err := failDeep(2 * helperFrames); !isTest(err) {
+		t.Fatalf("helpers deeper than the extra frames: %v", top(err).Function)
+	}
+	full := failDeep(2 * helperFrames).(*WithStackInfo)
+	SetStackDepth(2)
+	err := failDeep(2 * helperFrames)
+	SetStackDepth(0)
+	if w := err.(*WithStackInfo); !isTest(err) || len(*w.Stack) != 2 || w.omitted != len(*full.Stack)-2 {
+		t.Fatalf("a limited depth: %+v", err)
+	}
+
+	RegisterHelperPackage("gopkg.in/hedzr/errors.v3/")
+	if f := top(New("x")); f.Package != "testing" {
+		t.Fatalf("RegisterHelperPackage: %v", f.Function)
+	}
+}
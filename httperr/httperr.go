@@ -0,0 +1,218 @@
+// Copyright © 2023 Hedzr Yeh.
+
+// Package httperr bridges errors.Code and net/http.
+//
+// It maps a Code to an HTTP status code and back, and writes an
+// error to an http.ResponseWriter:
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//	    if err := do(r); err != nil {
+//	        httperr.WriteError(w, r, err)
+//	        return
+//	    }
+//	}
+package httperr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+// StatusOf returns the HTTP status code mapped from code.
+func StatusOf(code errors.Code) int {
+	return code.HTTPStatus()
+}
+
+var statusToCode = map[int]errors.Code{
+	http.StatusOK:                  errors.OK,
+	http.StatusBadRequest:          errors.BadRequest,
+	http.StatusUnauthorized:        errors.Unauthenticated,
+	http.StatusForbidden:           errors.Forbidden,
+	http.StatusNotFound:            errors.NotFound,
+	http.StatusMethodNotAllowed:    errors.MethodNotAllowed,
+	http.StatusRequestTimeout:      errors.Timeout,
+	http.StatusConflict:            errors.Conflict,
+	http.StatusTooManyRequests:     errors.RateLimited,
+	499:                            errors.Canceled, // client closed request
+	http.StatusInternalServerError: errors.InternalServerError,
+	http.StatusNotImplemented:      errors.Unimplemented,
+	http.StatusServiceUnavailable:  errors.Unavailable,
+	http.StatusGatewayTimeout:      errors.DeadlineExceeded,
+}
+
+// CodeFromStatus returns the Code mapped from an HTTP status code.
+//
+// The 2xx and 3xx statuses are mapped to OK, the unknown 4xx
+// statuses to BadRequest, and the others to Unknown.
+func CodeFromStatus(status int) errors.Code {
+	if c, ok := statusToCode[status]; ok {
+		return c
+	}
+	switch {
+	case status >= 200 && status < 400:
+		return errors.OK
+	case status >= 400 && status < 500:
+		return errors.BadRequest
+	}
+	return errors.Unknown
+}
+
+// CodeOf finds the most relevant Code in the error tree of err, see
+// errors.RelevantCode.
+//
+// If there is no Code in the tree, the well-known errors are mapped:
+// context.Canceled to Canceled, context.DeadlineExceeded to
+// DeadlineExceeded, os.ErrNotExist to NotFound and os.ErrPermission
+// to PermissionDenied. Otherwise Unknown returned.
+//
+// CodeOf returns OK for a nil error.
+func CodeOf(err error) errors.Code {
+	if err == nil {
+		return errors.OK
+	}
+
+	if code, ok := errors.RelevantCode(err); ok {
+		return code
+	}
+
+	for _, m := range wellKnown {
+		if errors.Is(err, m.err) {
+			return m.code
+		}
+	}
+	return errors.Unknown
+}
+
+var wellKnown = []struct {
+	err  error
+	code errors.Code
+}{
+	{context.Canceled, errors.Canceled},
+	{context.DeadlineExceeded, errors.DeadlineExceeded},
+	{os.ErrNotExist, errors.NotFound},
+	{os.ErrPermission, errors.PermissionDenied},
+}
+
+// Body is the JSON body written by WriteError.
+type Body struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// WriteError writes err to w with the HTTP status mapped from the
+// most relevant Code in err (see CodeOf).
+//
+// The body is a problem details document (see errors.Problem) if the
+// request accepts application/problem+json, a JSON object (see Body)
+// if it accepts application/json, or the plain text message
+// otherwise. The body carries the error message only, the stack
+// traces are never written.
+//
+// Nothing is written for a nil error.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+
+	code := CodeOf(err)
+	status := StatusOf(code)
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("X-Content-Type-Options", "nosniff")
+
+	switch accepted(r) {
+	case errors.ProblemContentType:
+		p := errors.ToProblem(err, instanceOf(r))
+		p.SetCode(code)
+		h.Set("Content-Type", errors.ProblemContentType)
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(p)
+	case "application/json":
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(&Body{Status: status, Code: code.String(), Message: err.Error()})
+	default:
+		h.Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(err.Error() + "\n"))
+	}
+}
+
+// WriteProblem writes err to w as a problem details document, without
+// the content negotiation of WriteError.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	p := errors.ToProblem(err, instanceOf(r))
+	p.SetCode(CodeOf(err))
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Type", errors.ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// instanceOf returns the request URI as the instance of a problem.
+func instanceOf(r *http.Request) string {
+	if r == nil || r.URL == nil {
+		return ""
+	}
+	return r.URL.RequestURI()
+}
+
+// accepted returns the media type accepted by r among
+// application/problem+json and application/json, or an empty string.
+// The other +json types are taken as application/json.
+//
+// The one with the highest q value wins, or the first one if the
+// values are equal. The types with q=0 are not acceptable.
+func accepted(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	var best string
+	bestQ := 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		params := strings.Split(part, ";")
+		mt := strings.TrimSpace(params[0])
+		switch {
+		case mt == errors.ProblemContentType:
+		case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+			mt = "application/json"
+		default:
+			continue
+		}
+		if q := qualityOf(params[1:]); q > bestQ {
+			best, bestQ = mt, q
+		}
+	}
+	return best
+}
+
+// qualityOf returns the q value in the parameters of a media range,
+// 1 if there is none, or 0 if it is malformed.
+func qualityOf(params []string) float64 {
+	for _, p := range params {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
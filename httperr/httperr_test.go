@@ -0,0 +1,145 @@
+package httperr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+func TestStatusMapping(t *testing.T) {
+	for _, c := range []errors.Code{errors.OK, errors.BadRequest, errors.Unauthenticated, errors.Forbidden,
+		errors.NotFound, errors.MethodNotAllowed, errors.Conflict, errors.RateLimited,
+		errors.Canceled, errors.InternalServerError, errors.Unimplemented, errors.Unavailable} {
+		if got := CodeFromStatus(StatusOf(c)); got != c {
+			t.Fatalf("round trip of %v failed, got %v", c, got)
+		}
+	}
+	if StatusOf(errors.InvalidArgument) != 400 || StatusOf(errors.Internal) != 500 {
+		t.Fatal("bad StatusOf")
+	}
+	if CodeFromStatus(418) != errors.BadRequest || CodeFromStatus(204) != errors.OK || CodeFromStatus(599) != errors.Unknown {
+		t.Fatal("bad CodeFromStatus for unknown statuses")
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want errors.Code
+	}{
+		{nil, errors.OK},
+		{io.EOF, errors.Unknown},
+		{errors.NotFound, errors.NotFound},
+		{errors.New("outer").WithErrors(errors.Internal.New("deep"), io.EOF).WithCode(errors.NotFound), errors.NotFound},
+		{errors.New("outer").WithErrors(io.EOF, errors.Forbidden.New("inner")), errors.Forbidden},
+		{fmt.Errorf("wrapped: %w", errors.Conflict.New("x")), errors.Conflict},
+		{errors.Wrap(context.DeadlineExceeded, "timeout"), errors.DeadlineExceeded},
+		{errors.Wrap(os.ErrNotExist, "open"), errors.NotFound},
+	}
+	for i, tt := range tests {
+		if got := CodeOf(tt.err); got != tt.want {
+			t.Errorf("%d. CodeOf(%v) = %v, want %v", i, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	err := errors.New("user %q", "bob").WithErrors(io.EOF).WithCode(errors.NotFound)
+
+	r := httptest.NewRequest("GET", "/users/bob", nil)
+	w := httptest.NewRecorder()
+	WriteError(w, r, err)
+	if w.Code != http.StatusNotFound || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("bad response: %d %v", w.Code, w.Header())
+	}
+	if body := w.Body.String(); body != err.Error()+"\n" || strings.Contains(body, ".go:") {
+		t.Fatalf("bad body: %q", body)
+	}
+
+	r.Header.Set("Accept", "text/html, application/json;q=0.9")
+	w = httptest.NewRecorder()
+	WriteError(w, r, err)
+	var b Body
+	if e := json.Unmarshal(w.Body.Bytes(), &b); e != nil {
+		t.Fatal(e)
+	}
+	if w.Code != http.StatusNotFound || b.Status != 404 || b.Code != "NOT_FOUND" || b.Message != err.Error() {
+		t.Fatalf("bad JSON response: %d %+v", w.Code, b)
+	}
+
+	w = httptest.NewRecorder()
+	WriteError(w, r, nil)
+	if w.Body.Len() != 0 {
+		t.Fatal("expecting nothing written for nil error")
+	}
+}
+
+func TestAccepted(t *testing.T) {
+	tests := []struct {
+		accept, want string
+	}{
+		{"", ""},
+		{"text/html, */*", ""},
+		{"text/html, application/json;q=0.9", "application/json"},
+		{"application/vnd.api+json", "application/json"},
+		{"application/json;q=0.5, application/problem+json", errors.ProblemContentType},
+		{"application/problem+json;q=0.5, application/json;q=0.8", "application/json"},
+		{"application/problem+json; q=0, application/json", "application/json"},
+		{"application/json;q=0", ""},
+		{"application/json;q=x", ""},
+	}
+	for i, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		if got := accepted(r); got != tt.want {
+			t.Errorf("%d. accepted(%q) = %q, want %q", i, tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	err := errors.Wrapf(os.ErrNotExist, "open config")
+
+	r := httptest.NewRequest("GET", "/config?v=1", nil)
+	r.Header.Set("Accept", "application/problem+json, application/json")
+	w := httptest.NewRecorder()
+	WriteError(w, r, err)
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != errors.ProblemContentType {
+		t.Fatalf("bad response: %d %v", w.Code, w.Header())
+	}
+
+	var p errors.Problem
+	if e := json.Unmarshal(w.Body.Bytes(), &p); e != nil {
+		t.Fatal(e)
+	}
+	if p.Title != "NOT_FOUND" || p.Status != 404 || p.Instance != "/config?v=1" || p.Detail != err.Error() {
+		t.Fatalf("bad problem: %+v", p)
+	}
+	if back := p.Err(); !errors.Is(back, errors.NotFound) {
+		t.Fatalf("bad decoded error: %v", back)
+	}
+
+	w = httptest.NewRecorder()
+	WriteProblem(w, r, errors.Internal.New("boom"))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), ".go:") {
+		t.Fatalf("bad response: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHTTPSentinels(t *testing.T) {
+	for _, s := range httpSentinels {
+		data, _ := errors.MarshalJSONWith(errors.Wrap(s.err, "serve"))
+		back, err := errors.UnmarshalJSONError(data)
+		if err != nil || !errors.Is(back, s.err) || !errors.Iss(back, s.err) {
+			t.Errorf("%s: decoded %s does not match", s.name, data)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package httperr
+
+import (
+	"net/http"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+func init() { //nolint:gochecknoinits
+	for _, s := range httpSentinels {
+		_ = errors.RegisterSentinel(s.name, s.err)
+	}
+}
+
+// httpSentinels lists the sentinels of net/http, they are registered
+// into errors.RegisterSentinel by importing this package.
+var httpSentinels = []struct {
+	name string
+	err  error
+}{
+	{"net/http.ErrAbortHandler", http.ErrAbortHandler},
+	{"net/http.ErrBodyNotAllowed", http.ErrBodyNotAllowed},
+	{"net/http.ErrBodyReadAfterClose", http.ErrBodyReadAfterClose},
+	{"net/http.ErrContentLength", http.ErrContentLength},
+	{"net/http.ErrHandlerTimeout", http.ErrHandlerTimeout},
+	{"net/http.ErrHijacked", http.ErrHijacked},
+	{"net/http.ErrLineTooLong", http.ErrLineTooLong},
+	{"net/http.ErrMissingBoundary", http.ErrMissingBoundary},
+	{"net/http.ErrMissingFile", http.ErrMissingFile},
+	{"net/http.ErrNoCookie", http.ErrNoCookie},
+	{"net/http.ErrNoLocation", http.ErrNoLocation},
+	{"net/http.ErrNotMultipart", http.ErrNotMultipart},
+	{"net/http.ErrNotSupported", http.ErrNotSupported},
+	{"net/http.ErrServerClosed", http.ErrServerClosed},
+	{"net/http.ErrSkipAltProtocol", http.ErrSkipAltProtocol},
+	{"net/http.ErrUseLastResponse", http.ErrUseLastResponse},
+}
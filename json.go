@@ -0,0 +1,177 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// jsonError is the JSON form of an error in an error tree, it is
+// described by error.schema.json.
+type jsonError struct {
+	Type          string                     `json:"type"`
+	Message       string                     `json:"message"`
+	MessageCauses int                        `json:"message_causes,omitempty"`
+	MessageWraps  bool                       `json:"message_wraps,omitempty"`
+	Sentinel      string                     `json:"sentinel,omitempty"`
+	Code          *jsonCode                  `json:"code,omitempty"`
+	Causes        []*jsonError               `json:"causes,omitempty"`
+	Data          []json.RawMessage          `json:"data,omitempty"`
+	TaggedData    map[string]json.RawMessage `json:"tagged_data,omitempty"`
+	Stack         []jsonFrame                `json:"stack,omitempty"`
+	StackOmitted  int                        `json:"stack_omitted,omitempty"`
+}
+
+// The Go type names of our errors in the JSON form.
+const (
+	codeTypeName          = "errors.Code"
+	withStackInfoTypeName = "*errors.WithStackInfo"
+)
+
+type jsonCode struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+}
+
+type jsonFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// JSONOpt is an option of MarshalJSONWith.
+type JSONOpt func(o *jsonOptions)
+
+type jsonOptions struct {
+	omitStack bool
+}
+
+// JSONOmitStack omits the stack frames from the JSON output.
+func JSONOmitStack(omit bool) JSONOpt {
+	return func(o *jsonOptions) { o.omitStack = omit }
+}
+
+var jsonOmitStack int32
+
+// SetJSONOmitStack sets whether MarshalJSON omits the stack frames,
+// it is the default of JSONOmitStack. The stack frames are included
+// by default.
+func SetJSONOmitStack(omit bool) {
+	var v int32
+	if omit {
+		v = 1
+	}
+	atomic.StoreInt32(&jsonOmitStack, v)
+}
+
+func defaultJSONOptions() jsonOptions {
+	return jsonOptions{omitStack: atomic.LoadInt32(&jsonOmitStack) != 0}
+}
+
+// MarshalJSONWith returns the JSON encoding of the whole tree of err,
+// with the options.
+//
+// Each error of the tree is encoded as an object with its Go type,
+// message, Code by name and number, inner errors as causes, Data,
+// TaggedData and the resolved stack frames. See error.schema.json
+// for the details.
+//
+//	data, _ := errors.MarshalJSONWith(err, errors.JSONOmitStack(true))
+//
+// A nil err is encoded as null.
+func MarshalJSONWith(err error, opts ...JSONOpt) ([]byte, error) {
+	o := defaultJSONOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(toJSONError(err, &o, make(visitedSet)))
+}
+
+// MarshalJSON encodes w and its inner errors, see MarshalJSONWith.
+func (w *WithStackInfo) MarshalJSON() ([]byte, error) {
+	return MarshalJSONWith(w)
+}
+
+// MarshalJSON encodes w and its inner errors, see MarshalJSONWith.
+func (w *causes2) MarshalJSON() ([]byte, error) {
+	return MarshalJSONWith(w)
+}
+
+func toJSONError(err error, o *jsonOptions, ancestors visitedSet) *jsonError {
+	je := &jsonError{Type: fmt.Sprintf("%T", err)}
+	if !ancestors.enter(err, nil) {
+		je.Message = cycleMarker
+		return je
+	}
+	defer ancestors.leave(err, nil)
+
+	je.Sentinel, _ = SentinelName(err)
+
+	var causes []error
+	switch e := err.(type) {
+	case Code:
+		je.Code = &jsonCode{Name: e.String(), Number: int(e)}
+		je.Message = e.String()
+		return je
+	case *WithStackInfo:
+		je.Message, je.MessageCauses, je.MessageWraps, causes = e.message(), e.msgCauses, e.wrapsCause, e.Causers
+		if e.remoteType != "" {
+			je.Type = e.remoteType
+		}
+		for _, d := range e.sites {
+			je.Data = append(je.Data, jsonValue(d))
+		}
+		if len(e.taggedSites) > 0 {
+			je.TaggedData = make(map[string]json.RawMessage, len(e.taggedSites))
+			for k, v := range e.taggedSites {
+				je.TaggedData[k] = jsonValue(v)
+			}
+		}
+		if !o.omitStack {
+			je.Stack = jsonFrames(e.Stack)
+			for _, f := range e.remote {
+				je.Stack = append(je.Stack, jsonFrame(f))
+			}
+			je.StackOmitted = e.omitted
+		}
+	case interface{ self() *causes2 }:
+		c := e.self()
+		je.Message, je.MessageCauses, je.MessageWraps, causes = c.message(), c.msgCauses, c.wrapsCause, c.Causers
+	default:
+		causes = Children(err)
+		je.Message, je.MessageCauses = err.Error(), len(causes)
+	}
+
+	if x, ok := err.(interface{ self() *causes2 }); ok && x.self().Code != OK {
+		c := x.self().Code
+		je.Code = &jsonCode{Name: c.String(), Number: int(c)}
+	}
+	for _, c := range causes {
+		if c != nil {
+			je.Causes = append(je.Causes, toJSONError(c, o, ancestors))
+		}
+	}
+	return je
+}
+
+func jsonFrames(s *Stack) (frames []jsonFrame) {
+	for _, f := range s.Frames() {
+		frames = append(frames, jsonFrame{Function: f.Function, File: f.File, Line: f.Line})
+	}
+	return
+}
+
+// jsonValue encodes v, or the fmt.Sprint form of v if it cannot be
+// encoded.
+func jsonValue(v interface{}) json.RawMessage { //nolint:revive
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return data
+}
@@ -0,0 +1,90 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	err := New("save %q", "a.txt").
+		WithErrors(io.EOF, fmt.Errorf("flush: %w", io.ErrShortWrite)).
+		WithCode(Internal).
+		WithData(42, func() {}).
+		WithTaggedData(TaggedData{"user": "bob"})
+
+	data, e := json.Marshal(err)
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Logf("%s", data)
+
+	var je jsonError
+	if e = json.Unmarshal(data, &je); e != nil {
+		t.Fatal(e)
+	}
+	if je.Type != "*errors.WithStackInfo" || je.Message != `save "a.txt"` ||
+		je.Code == nil || je.Code.Name != "INTERNAL" || je.Code.Number != int(Internal) {
+		t.Fatalf("bad root: %+v", je)
+	}
+	if len(je.Causes) != 2 || je.Causes[0].Message != "EOF" ||
+		len(je.Causes[1].Causes) != 1 || je.Causes[1].Causes[0].Message != "short write" {
+		t.Fatalf("bad causes: %s", data)
+	}
+	if len(je.Data) != 2 || string(je.Data[0]) != "42" || string(je.TaggedData["user"]) != `"bob"` {
+		t.Fatalf("bad data: %s", data)
+	}
+	if len(je.Stack) == 0 || !strings.HasSuffix(je.Stack[0].Function, ".TestMarshalJSON") ||
+		!strings.HasSuffix(je.Stack[0].File, "json_test.go") || je.Stack[0].Line == 0 {
+		t.Fatalf("bad stack: %+v", je.Stack)
+	}
+
+	data, _ = MarshalJSONWith(err, JSONOmitStack(true))
+	if strings.Contains(string(data), `"stack"`) {
+		t.Fatalf("stack not omitted: %s", data)
+	}
+
+	SetJSONOmitStack(true)
+	defer SetJSONOmitStack(false)
+	data, _ = json.Marshal(Wrap(err, "wrapped"))
+	if strings.Contains(string(data), `"stack"`) || !strings.Contains(string(data), `"bob"`) {
+		t.Fatalf("bad output: %s", data)
+	}
+	if data, _ = MarshalJSONWith(nil); string(data) != "null" {
+		t.Fatalf("bad nil: %s", data)
+	}
+}
+
+// TestJSONSchema checks that the properties written are all described
+// by the schema document.
+func TestJSONSchema(t *testing.T) {
+	raw, err := ioutil.ReadFile("error.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err = json.Unmarshal(raw, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(New("x").WithErrors(io.EOF).WithCode(NotFound).WithData(1).WithTaggedData(TaggedData{"a": 1}))
+	var doc map[string]interface{} //nolint:revive
+	_ = json.Unmarshal(data, &doc)
+	for k := range doc {
+		if _, ok := schema.Defs["error"].Properties[k]; !ok {
+			t.Errorf("property %q is not in the schema", k)
+		}
+	}
+	for k := range doc["stack"].([]interface{})[0].(map[string]interface{}) {
+		if _, ok := schema.Defs["frame"].Properties[k]; !ok {
+			t.Errorf("frame property %q is not in the schema", k)
+		}
+	}
+}
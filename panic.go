@@ -0,0 +1,124 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Recover recovers a panic and sets it into *err as an Error, see
+// FromPanic. It must be deferred directly:
+//
+//	func (s *Server) handle(req *Request) (err error) {
+//	    defer errors.Recover(&err)
+//	    ...
+//	}
+//
+// If *err holds an error already, it is attached as a cause of the
+// recovered one. Recover does nothing if the goroutine is not
+// panicking, and a nil err does not stop the panic.
+func Recover(err *error) {
+	if err == nil {
+		return
+	}
+	r := recover()
+	if r == nil {
+		return
+	}
+	e := fromPanic(r)
+	if *err != nil {
+		e.Attach(*err)
+	}
+	*err = e
+}
+
+// FromPanic returns an Error made from the value r recovered from a
+// panic:
+//
+//	defer func() {
+//	    if r := recover(); r != nil {
+//	        err = errors.FromPanic(r)
+//	    }
+//	}()
+//
+// The stack starts at the panic site, the frames of runtime.gopanic
+// and the deferred functions are skipped. For a fault, such as a nil
+// pointer dereference, the runtime.sigpanic frame above the faulting
+// one is kept, it is elided from %+v by the default FrameFilter.
+//
+// An error panic is wrapped as the cause, the other values are kept
+// in Data. A runtime.Error panic, such as a nil pointer dereference,
+// has the Code Internal.
+//
+// FromPanic returns nil for a nil r.
+func FromPanic(r interface{}) Error { //nolint:revive
+	if r == nil {
+		return nil
+	}
+	return fromPanic(r)
+}
+
+func fromPanic(r interface{}) *WithStackInfo { //nolint:revive
+	w := &WithStackInfo{}
+	if err, ok := r.(error); ok {
+		w.Causers, w.msg, w.msgCauses, w.wrapsCause = []error{err}, "panic", 1, true
+		if _, ok = err.(runtime.Error); ok {
+			w.Code = Internal
+		}
+	} else {
+		w.msg, w.sites = fmt.Sprintf("panic: %v", r), []interface{}{r} //nolint:revive
+	}
+	w.Stack, w.omitted = panicCallers(2)
+	return w
+}
+
+// panicCallers records the stack from the panic site, or from the
+// caller identified by skip if the goroutine is not panicking. The
+// stack is truncated by StackDepth, the number of the omitted frames
+// is returned too.
+func panicCallers(skip int) (st *Stack, omitted int) {
+	s, _ := callers(skip+1, stackOpts{depth: UnlimitedStackDepth, policy: CaptureFull})
+	pcs := []uintptr(*s)
+	if i := panicSite(pcs); i > 0 {
+		pcs = pcs[i:]
+	}
+	if depth := StackDepth(); depth > 0 && len(pcs) > depth {
+		omitted, pcs = len(pcs)-depth, pcs[:depth]
+	}
+	ps := Stack(append([]uintptr(nil), pcs...))
+	return &ps, omitted
+}
+
+// panicSite returns the index of the frame which panicked, it is the
+// first one out of the runtime package below runtime.gopanic. 0 is
+// returned if runtime.gopanic is not found.
+//
+// The pc after runtime.sigpanic is the faulting instruction rather
+// than a return address, the index of the runtime.sigpanic frame is
+// returned for it, so that resolveFrames resolves it as such.
+func panicSite(pcs []uintptr) (site int) {
+	i, panicking, sigpanic := 0, false, false
+	walkPCs(pcs, func(_ frameKey, r resolvedPC) bool {
+		var fn string
+		if n := len(r.frames); n > 0 {
+			fn = r.frames[n-1].Function
+		}
+		switch {
+		case !panicking:
+			panicking = fn == "runtime.gopanic"
+		case !strings.HasPrefix(fn, "runtime."):
+			site = i
+			if sigpanic {
+				site--
+			}
+			return false
+		default:
+			sigpanic = fn == "runtime.sigpanic"
+		}
+		i++
+		return true
+	})
+	return
+}
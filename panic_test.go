@@ -0,0 +1,115 @@
+package errors
+
+import (
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type panicPoint struct{ n int }
+
+var panicLine int
+
+//go:noinline
+func panicNil(p *panicPoint) (err error) {
+	defer Recover(&err)
+	_, _, panicLine, _ = runtime.Caller(0)
+	p.n++ // the line after panicLine
+	return
+}
+
+//go:noinline
+func panicIndex(i int) (err error) {
+	defer Recover(&err)
+	a := []int{1}
+	_ = a[i]
+	return
+}
+
+//go:noinline
+func panicValue(v interface{}) (err error) { //nolint:revive
+	defer func() {
+		if r := recover(); r != nil {
+			err = FromPanic(r)
+		}
+	}()
+	panic(v)
+}
+
+//go:noinline
+func panicOver(prev error) (err error) {
+	defer Recover(&err)
+	err = prev
+	panic("over")
+}
+
+//go:noinline
+func panicNoErr() {
+	defer Recover(nil)
+	panic("no err")
+}
+
+func TestRecover(t *testing.T) {
+	top := func(err error) ResolvedFrame { return DefaultFrameFilter().Filter(err.(*WithStackInfo).Frames())[0] }
+
+	err := panicNil(nil)
+	if err == nil || !Is(err, Internal) {
+		t.Fatalf("a runtime error should be Internal: %+v", err)
+	}
+	if f := err.(*WithStackInfo).Frames()[0]; f.Function != "runtime.sigpanic" {
+		t.Fatalf("the sigpanic frame should be kept for the faulting pc: %+v", err)
+	}
+	if f := top(err); !strings.HasSuffix(f.Function, ".panicNil") || f.Line != panicLine+1 {
+		t.Fatalf("the stack should start at the faulting line %d: %+v", panicLine+1, err)
+	}
+	if _, ok := Causes(err)[0].(runtime.Error); !ok {
+		t.Fatalf("the runtime error should be the cause: %+v", err)
+	}
+
+	err = panicIndex(3)
+	if f := top(err); !strings.HasSuffix(f.Function, ".panicIndex") || !Is(err, Internal) {
+		t.Fatalf("the stack should start at panicIndex: %+v", err)
+	}
+	SetStackDepth(2)
+	w := panicIndex(3).(*WithStackInfo)
+	SetStackDepth(0)
+	if n := len(*err.(*WithStackInfo).Stack); len(*w.Stack) != 2 || w.omitted != n-2 {
+		t.Fatalf("bad panic stack: %d frames, %d omitted of %d", len(*w.Stack), w.omitted, n)
+	}
+
+	err = panicValue(io.EOF)
+	if !Is(err, io.EOF) || Is(err, Internal) || !strings.HasSuffix(top(err).Function, ".panicValue") {
+		t.Fatalf("an error panic should be the cause: %+v", err)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "panic: EOF") {
+		t.Fatalf("bad message: %q", msg)
+	}
+
+	err = panicValue("boom")
+	if d := err.(*WithStackInfo).Data(); len(d) != 1 || d[0] != "boom" || err.Error() != "panic: boom" {
+		t.Fatalf("a value panic should be kept in Data: %+v", err)
+	}
+
+	err = panicOver(io.ErrUnexpectedEOF)
+	if !Is(err, io.ErrUnexpectedEOF) || !strings.HasPrefix(err.Error(), "panic: over") {
+		t.Fatalf("the previous error should be attached: %+v", err)
+	}
+
+	if FromPanic(nil) != nil {
+		t.Fatal("FromPanic(nil) should be nil")
+	}
+	if err = FromPanic("outside"); !strings.HasSuffix(top(err).Function, ".TestRecover") {
+		t.Fatalf("out of panicking, the stack should start at the caller: %+v", err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "no err" {
+				t.Fatalf("Recover(nil) should not stop the panic: %v", r)
+			}
+		}()
+		panicNoErr()
+		t.Fatal("the panic is lost")
+	}()
+}
@@ -0,0 +1,152 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// PathMode tells how the source file paths are printed by %+s and
+// %+v of Frame, ResolvedFrame, StackTrace and Stack.
+//
+// The paths recorded by the compiler are the absolute ones on the
+// build machine, such as /home/ci/go/pkg/mod/github.com/x/y@v1.2.3/z.go,
+// which are noisy and leak the layout of the CI. They can be trimmed:
+//
+//	errors.SetPathMode(errors.PathModule)
+type PathMode int32
+
+const (
+	// PathFull prints the absolute paths, it is the default.
+	PathFull PathMode = iota
+	// PathModule prints the files of the main module relative to its
+	// root, such as "internal/db/conn.go". The other files are
+	// printed as PathModCache does.
+	PathModule
+	// PathModCache prints the files in the module cache (GOMODCACHE)
+	// with the module@version prefix, such as
+	// "github.com/x/y@v1.2.3/z.go", and the files in GOPATH relative
+	// to GOPATH/src. The other files are printed in full.
+	PathModCache
+	// PathBase prints the base names of the files only.
+	PathBase
+)
+
+var pathModeNames = [...]string{"FULL", "MODULE", "MODCACHE", "BASE"}
+
+// String for stringer interface
+func (m PathMode) String() string {
+	if m >= 0 && int(m) < len(pathModeNames) {
+		return pathModeNames[m]
+	}
+	return "UNKNOWN"
+}
+
+var pathMode int32
+
+// SetPathMode sets how the source file paths are printed, see
+// PathMode.
+func SetPathMode(m PathMode) {
+	atomic.StoreInt32(&pathMode, int32(m))
+}
+
+// GetPathMode returns how the source file paths are printed.
+func GetPathMode() PathMode {
+	return PathMode(atomic.LoadInt32(&pathMode))
+}
+
+// trimPath returns file of the function in pkg, trimmed by the path
+// mode.
+func trimPath(file, pkg string) string {
+	switch GetPathMode() {
+	case PathModule:
+		if rel, ok := mainModule.rel(file, pkg); ok {
+			return rel
+		}
+		return trimModCache(file)
+	case PathModCache:
+		return trimModCache(file)
+	case PathBase:
+		return path.Base(file)
+	}
+	return file
+}
+
+// moduleRoot finds the root directory of the main module on the
+// build machine, from the files of the packages in it.
+type moduleRoot struct {
+	sync.RWMutex
+	once sync.Once
+	path string // the module path, empty if unknown
+	dir  string // the root directory, empty until found
+}
+
+var mainModule moduleRoot
+
+// rel returns file relative to the root of the main module.
+func (m *moduleRoot) rel(file, pkg string) (string, bool) {
+	m.once.Do(func() { m.path = mainModulePath() })
+
+	if m.path != "" && (pkg == m.path || strings.HasPrefix(pkg, m.path+"/")) {
+		// the directory of a package is its path under the root
+		sub := strings.TrimPrefix(strings.TrimPrefix(pkg, m.path), "/")
+		if dir := path.Dir(file); sub == "" || strings.HasSuffix(dir, "/"+sub) {
+			m.Lock()
+			if m.dir == "" {
+				m.dir = strings.TrimSuffix(strings.TrimSuffix(dir, sub), "/")
+			}
+			m.Unlock()
+		}
+		return path.Join(sub, path.Base(file)), true
+	}
+
+	// package main and the others which are not named by the module
+	// path, such as the external test packages
+	m.RLock()
+	dir := m.dir
+	m.RUnlock()
+	if dir != "" && strings.HasPrefix(file, dir+"/") {
+		return file[len(dir)+1:], true
+	}
+	return "", false
+}
+
+var (
+	goPathsOnce sync.Once
+	modCache    string   // GOMODCACHE with slashes
+	goPaths     []string // GOPATH entries with slashes
+)
+
+// modCacheMarker is the module cache in the default GOPATH layout, it
+// is used if GOMODCACHE differs on the build machine.
+const modCacheMarker = "/pkg/mod/"
+
+// trimModCache returns file relative to the module cache or GOPATH/src.
+func trimModCache(file string) string {
+	goPathsOnce.Do(func() {
+		modCache = filepath.ToSlash(os.Getenv("GOMODCACHE"))
+		for _, p := range filepath.SplitList(os.Getenv("GOPATH")) {
+			if p != "" {
+				goPaths = append(goPaths, filepath.ToSlash(p))
+			}
+		}
+	})
+
+	if modCache != "" && strings.HasPrefix(file, modCache+"/") {
+		return file[len(modCache)+1:]
+	}
+	if i := strings.LastIndex(file, modCacheMarker); i >= 0 && strings.Contains(file[i:], "@") {
+		return file[i+len(modCacheMarker):]
+	}
+	for _, p := range goPaths {
+		if strings.HasPrefix(file, p+"/src/") {
+			return file[len(p)+len("/src/"):]
+		}
+	}
+	return file
+}
@@ -0,0 +1,10 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build !go1.12
+// +build !go1.12
+
+package errors
+
+// mainModulePath returns empty since runtime/debug.ReadBuildInfo is
+// not available, PathModule prints the paths as PathModCache does.
+func mainModulePath() string { return "" }
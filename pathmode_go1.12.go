@@ -0,0 +1,19 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.12
+// +build go1.12
+
+package errors
+
+import (
+	"runtime/debug"
+)
+
+// mainModulePath returns the path of the main module, empty if the
+// binary is not built in module mode.
+func mainModulePath() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Path
+	}
+	return ""
+}
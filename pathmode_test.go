@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPathMode(t *testing.T) {
+	defer SetPathMode(PathFull)
+
+	f := New("trimmed").(*WithStackInfo).Frames()[0]
+	for _, c := range []struct {
+		mode PathMode
+		want string
+	}{
+		{PathFull, "\n\t" + f.File + ":"},
+		{PathModule, "\n\tpathmode_test.go:"},
+		{PathBase, "\n\tpathmode_test.go:"},
+	} {
+		SetPathMode(c.mode)
+		if text := fmt.Sprintf("%+v", f); !strings.Contains(text, c.want) {
+			t.Errorf("%v: expect %q in %q", c.mode, c.want, text)
+		}
+	}
+
+	SetPathMode(PathModule)
+	text := fmt.Sprintf("%+v", New("trimmed"))
+	if strings.Contains(text, f.File) {
+		t.Fatalf("the full path is not trimmed:\n%s", text)
+	}
+}
+
+func TestTrimPath(t *testing.T) {
+	for _, c := range []struct{ file, want string }{
+		{"/home/ci/go/pkg/mod/github.com/x/y@v1.2.3/z/z.go", "github.com/x/y@v1.2.3/z/z.go"},
+		{"/home/ci/pkg/mod/z.go", "/home/ci/pkg/mod/z.go"},
+		{"/usr/local/go/src/runtime/proc.go", "/usr/local/go/src/runtime/proc.go"},
+	} {
+		if got := trimModCache(c.file); got != c.want {
+			t.Errorf("trimModCache(%q) = %q, want %q", c.file, got, c.want)
+		}
+	}
+
+	m := &moduleRoot{}
+	m.once.Do(func() { m.path = "example.com/app" })
+	for _, c := range []struct {
+		file, pkg, want string
+		ok              bool
+	}{
+		{"/src/app/main.go", "main", "", false}, // the root is unknown yet
+		{"/src/app/internal/db/conn.go", "example.com/app/internal/db", "internal/db/conn.go", true},
+		{"/src/app/main.go", "main", "main.go", true},
+		{"/src/app/cmd/tool/main.go", "main", "cmd/tool/main.go", true},
+		{"/src/lib/lib.go", "example.com/lib", "", false},
+	} {
+		if got, ok := m.rel(c.file, c.pkg); got != c.want || ok != c.ok {
+			t.Errorf("rel(%q, %q) = %q, %v, want %q, %v", c.file, c.pkg, got, ok, c.want, c.ok)
+		}
+	}
+}
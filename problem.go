@@ -0,0 +1,317 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// ProblemContentType is the media type of a problem details document.
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details document defined by RFC 9457.
+//
+// A Problem is made from an error by ToProblem on the server side,
+// and turned back into an Error by Problem.Err on the client side:
+//
+//	// server
+//	w.Header().Set("Content-Type", errors.ProblemContentType)
+//	w.WriteHeader(p.Status)
+//	_ = json.NewEncoder(w).Encode(errors.ToProblem(err, r.URL.RequestURI()))
+//
+//	// client
+//	err, _ := errors.DecodeProblem(resp.Body)
+//	if errors.Is(err, errors.NotFound) {
+//	    ...
+//	}
+type Problem struct {
+	Type     string // the URI reference identifying the problem type, the DocURL of the Code
+	Title    string // the short summary of the problem type, the name of the Code
+	Status   int    // the HTTP status code
+	Detail   string // the explanation specific to this occurrence, the error message
+	Instance string // the URI reference identifying this occurrence
+
+	// Extensions holds the extension members, the TaggedData of the
+	// error.
+	Extensions TaggedData
+}
+
+// problemMembers lists the members defined by RFC 9457, the
+// extensions with these names are ignored.
+var problemMembers = map[string]bool{
+	"type": true, "title": true, "status": true, "detail": true, "instance": true,
+}
+
+// NewProblem returns a Problem for code.
+func NewProblem(code Code, detail, instance string) *Problem {
+	p := &Problem{Detail: detail, Instance: instance}
+	p.SetCode(code)
+	return p
+}
+
+// ToProblem returns a Problem describing err.
+//
+// The Code is the non-OK Code nearest to the root of the error tree,
+// Unknown if there is none. The detail is the message of err, and the
+// TaggedData of err become the extension members. The stack traces
+// are never included.
+//
+// The "type" and "instance" TaggedData, which are kept by Problem.Err,
+// are taken back as the Type and the Instance. The instance argument
+// wins if it is not empty.
+func ToProblem(err error, instance string) *Problem {
+	if err == nil {
+		return NewProblem(OK, "", instance)
+	}
+
+	code, _ := RelevantCode(err)
+	p := NewProblem(code, problemDetail(err), instance)
+	if x, ok := err.(interface{ TaggedData() TaggedData }); ok {
+		for k, v := range x.TaggedData() {
+			s, _ := v.(string)
+			switch {
+			case !problemMembers[k]:
+				p.setExtension(k, v)
+			case k == "type" && s != "":
+				p.Type = s
+			case k == "instance" && p.Instance == "":
+				p.Instance = s
+			}
+		}
+	}
+	if names := sentinelNames(err); len(names) > 0 {
+		p.setExtension(problemSentinels, names)
+	}
+	return p
+}
+
+// problemSentinels is the extension member holding the names of the
+// registered sentinels in the error tree, see RegisterSentinel.
+const problemSentinels = "sentinels"
+
+func (p *Problem) setExtension(name string, value interface{}) { //nolint:revive
+	if p.Extensions == nil {
+		p.Extensions = make(TaggedData)
+	}
+	p.Extensions[name] = value
+}
+
+// sentinelNames returns the names of the registered sentinels in err's
+// tree, in the order of Walk.
+func sentinelNames(err error) (names []string) {
+	seen := make(map[string]bool)
+	_ = Walk(err, func(e error, depth int, path []int, parent error) error {
+		if name, ok := SentinelName(e); ok {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			return SkipChildren
+		}
+		return nil
+	})
+	return
+}
+
+// SetCode sets the Type, Title and Status of p from code.
+func (p *Problem) SetCode(code Code) {
+	info := code.Info()
+	p.Type, p.Title, p.Status = info.DocURL, code.String(), info.HTTPStatus
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+}
+
+// Code returns the Code identified by p.
+//
+// The Code is looked up by the Type first, then the Title. If neither
+// is registered, the first builtin Code mapped to the Status is
+// returned, or Unknown. If several codes are registered with the same
+// DocURL, the Type identifies the first one.
+func (p *Problem) Code() Code {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if c, ok := docURLToCode[p.Type]; ok && p.Type != "about:blank" {
+		return c
+	}
+	if c, ok := strToCode[p.Title]; ok {
+		return c
+	}
+	for c := OK; c >= UnsupportedVersion; c-- {
+		if codeInfos[c].HTTPStatus == p.Status {
+			return c
+		}
+	}
+	return Unknown
+}
+
+// Err returns an Error rebuilt from p, so that Is(err, code) works
+// on the client side. The extension members become its TaggedData,
+// except the "sentinels" member: the sentinels named by it are
+// attached as the causes, so Is(err, sentinel) works too.
+//
+// The Instance, and the Type if it is not the one of the Code, are
+// kept as the "instance" and "type" TaggedData, so ToProblem gives
+// them back.
+//
+// Err returns nil for a Problem with the OK Code.
+func (p *Problem) Err() Error {
+	code := p.Code()
+	if code == OK {
+		return nil
+	}
+	w := &WithStackInfo{causes2: causes2{Code: code, msg: p.Detail}}
+	w.Stack, w.omitted = callers(1, stackOpts{})
+	for k, v := range p.Extensions {
+		if k != problemSentinels {
+			_ = w.WithTaggedData(TaggedData{k: v})
+			continue
+		}
+		for _, name := range stringsOf(v) {
+			if s, ok := LookupSentinel(name); ok {
+				w.Causers = append(w.Causers, s)
+			}
+		}
+	}
+	w.msgCauses = len(w.Causers) // the detail tells about them already
+
+	var q Problem
+	q.SetCode(code)
+	if p.Type != "" && p.Type != q.Type {
+		_ = w.WithTaggedData(TaggedData{"type": p.Type})
+	}
+	if p.Instance != "" {
+		_ = w.WithTaggedData(TaggedData{"instance": p.Instance})
+	}
+	return w
+}
+
+// stringsOf returns the strings in v, which is a []string, or a
+// []interface{} decoded from JSON.
+func stringsOf(v interface{}) (ss []string) { //nolint:revive
+	switch x := v.(type) {
+	case []string:
+		return x
+	case []interface{}: //nolint:revive
+		for _, e := range x {
+			if s, ok := e.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+	}
+	return
+}
+
+// MarshalJSON encodes p as a JSON object, the extension members are
+// placed after the standard ones, in the order of their names.
+//
+// An extension value which cannot be encoded is written as its
+// fmt.Sprint form.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	_ = buf.WriteByte('{')
+	member := func(name string, value interface{}) { //nolint:revive
+		data := jsonValue(value)
+		if buf.Len() > 1 {
+			_ = buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		_, _ = buf.Write(key)
+		_ = buf.WriteByte(':')
+		_, _ = buf.Write(data)
+	}
+
+	if p.Type != "" {
+		member("type", p.Type)
+	}
+	if p.Title != "" {
+		member("title", p.Title)
+	}
+	if p.Status != 0 {
+		member("status", p.Status)
+	}
+	if p.Detail != "" {
+		member("detail", p.Detail)
+	}
+	if p.Instance != "" {
+		member("instance", p.Instance)
+	}
+
+	keys := make([]string, 0, len(p.Extensions))
+	for k := range p.Extensions {
+		if !problemMembers[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		member(k, p.Extensions[k])
+	}
+
+	_ = buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object into p. The members which are
+// not defined by RFC 9457 are saved into Extensions.
+//
+// A standard member with a wrong type is ignored, as RFC 9457
+// requires.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var members map[string]interface{} //nolint:revive
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	*p = Problem{}
+	for k, v := range members {
+		switch k {
+		case "type":
+			p.Type, _ = v.(string)
+		case "title":
+			p.Title, _ = v.(string)
+		case "status":
+			if f, ok := v.(float64); ok {
+				p.Status = int(f)
+			}
+		case "detail":
+			p.Detail, _ = v.(string)
+		case "instance":
+			p.Instance, _ = v.(string)
+		default:
+			if p.Extensions == nil {
+				p.Extensions = make(TaggedData)
+			}
+			p.Extensions[k] = v
+		}
+	}
+	return nil
+}
+
+// DecodeProblem reads a problem details document from r, and returns
+// the Error rebuilt from it (see Problem.Err).
+func DecodeProblem(r io.Reader) (Error, error) {
+	var p Problem
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+	return p.Err(), nil
+}
+
+// problemDetail returns the message of err for the detail member.
+// The message of a bare Code is empty, since it is the title already.
+func problemDetail(err error) string {
+	switch e := err.(type) {
+	case Code:
+		return ""
+	case interface{ self() *causes2 }:
+		if msg := e.self().headlineV(make(visitedSet)); msg != "" {
+			return msg
+		}
+	}
+	return err.Error()
+}
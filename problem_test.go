@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestProblem(t *testing.T) {
+	err := New("user %q not found", "bob").
+		WithErrors(io.EOF).
+		WithCode(NotFound).
+		WithTaggedData(TaggedData{"user": "bob", "status": "ignored"})
+
+	p := ToProblem(err, "/users/bob")
+	data, e := json.Marshal(p)
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Logf("%s", data)
+	want := `{"type":"https://pkg.go.dev/gopkg.in/hedzr/errors.v3#NotFound","title":"NOT_FOUND","status":404,"detail":"user \"bob\" not found","instance":"/users/bob","sentinels":["io.EOF"],"user":"bob"}`
+	if string(data) != want {
+		t.Fatalf("bad problem:\n got %s\nwant %s", data, want)
+	}
+
+	back, e := DecodeProblem(strings.NewReader(string(data)))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !Is(back, NotFound) || Is(back, Internal) {
+		t.Fatalf("bad decoded error: %v", back)
+	}
+	if back.TaggedData()["user"] != "bob" || back.Error() != `user "bob" not found [NOT_FOUND]` {
+		t.Fatalf("bad decoded error: %v, %v", back, back.TaggedData())
+	}
+}
+
+func TestProblemSentinels(t *testing.T) {
+	err := Wrap(io.ErrUnexpectedEOF, "read body").WithCode(BadRequest)
+
+	data, _ := json.Marshal(ToProblem(err, ""))
+	if !strings.Contains(string(data), `"sentinels":["io.ErrUnexpectedEOF"]`) {
+		t.Fatalf("bad problem: %s", data)
+	}
+	back, e := DecodeProblem(strings.NewReader(string(data)))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !Is(back, io.ErrUnexpectedEOF) || !Is(back, BadRequest) || Is(back, io.EOF) {
+		t.Fatalf("bad decoded error: %v", back)
+	}
+	if back.Error() != "read body [BAD_REQUEST]" || len(back.TaggedData()) != 0 {
+		t.Fatalf("bad decoded error: %v, %v", back, back.TaggedData())
+	}
+}
+
+func TestProblemCode(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want Code
+	}{
+		{`{"type":"https://pkg.go.dev/gopkg.in/hedzr/errors.v3#Conflict","title":"whatever"}`, Conflict},
+		{`{"type":"https://example.com/probs/out-of-credit","title":"PERMISSION_DENIED","status":400}`, PermissionDenied},
+		{`{"title":"Service Unavailable","status":503}`, Unavailable},
+		{`{"status":"not a number"}`, Unknown},
+	}
+	for i, tt := range tests {
+		var p Problem
+		if err := json.Unmarshal([]byte(tt.doc), &p); err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Code(); got != tt.want {
+			t.Errorf("%d. Code() = %v, want %v", i, got, tt.want)
+		}
+	}
+
+	if p := ToProblem(io.EOF, ""); p.Status != 500 || p.Title != "UNKNOWN" || p.Detail != "EOF" {
+		t.Fatalf("bad problem for a plain error: %+v", p)
+	}
+	if p := ToProblem(Forbidden, ""); p.Status != 403 || p.Detail != "" {
+		t.Fatalf("bad problem for a Code: %+v", p)
+	}
+	if (&Problem{Status: 200}).Err() != nil {
+		t.Fatal("expecting nil error for an OK problem")
+	}
+}
+
+func TestProblemRoundTrip(t *testing.T) {
+	const doc = `{"type":"https://example.com/probs/out-of-credit","title":"FORBIDDEN","status":403,"detail":"your balance is 30","instance":"/account/12345/msgs/abc","balance":30}`
+	back, e := DecodeProblem(strings.NewReader(doc))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !Is(back, Forbidden) {
+		t.Fatalf("bad decoded error: %v", back)
+	}
+
+	data, _ := json.Marshal(ToProblem(back, ""))
+	if string(data) != doc {
+		t.Fatalf("the round trip changed the problem:\n got %s\nwant %s", data, doc)
+	}
+
+	p := ToProblem(back, "/other")
+	if p.Instance != "/other" || p.Type != "https://example.com/probs/out-of-credit" {
+		t.Fatalf("bad problem: %+v", p)
+	}
+}
+
+func TestProblemSharedDocURL(t *testing.T) {
+	const url = "https://example.com/probs/shared"
+	c1 := RegisterCode(811, "PROBLEM_TEST_811", CodeInfo{DocURL: url})
+	c2 := RegisterCode(812, "PROBLEM_TEST_812", CodeInfo{DocURL: url})
+	if c1 == c2 {
+		t.Fatal("expecting two codes")
+	}
+	for i := 0; i < 20; i++ {
+		if got := (&Problem{Type: url}).Code(); got != c1 {
+			t.Fatalf("%d. Code() = %v, want the first registered %v", i, got, c1)
+		}
+	}
+}
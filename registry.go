@@ -0,0 +1,260 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// builtinOwner owns the builtin Codes and the range (MinErrorCode, 0].
+const builtinOwner = "gopkg.in/hedzr/errors.v3"
+
+// ErrRegistryFrozen is returned by the registering functions after
+// Freeze has been called.
+var ErrRegistryFrozen = errors.New("errors: code registry is frozen") //nolint:revive
+
+// CodeConflictError describes why a Code cannot be registered, and
+// who owns the conflicting name, number or range already.
+//
+// It matches AlreadyExists by Is:
+//
+//	_, err := errors.TryRegisterCode(3, "cannot ack")
+//	if errors.Is(err, errors.AlreadyExists) {
+//	    println(err.Error())
+//	}
+type CodeConflictError struct {
+	Code  Code   // the requested code number
+	Name  string // the requested code name
+	Owner string // who requested
+
+	ExistingCode  Code   // the conflicting code number
+	ExistingName  string // the conflicting code name, or namespace name for a reserved range
+	ExistingOwner string // who owns the conflicting one
+}
+
+// Error for error interface
+func (e *CodeConflictError) Error() string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "errors: cannot register code %d as %q for %s: ", int(e.Code), e.Name, e.Owner)
+	switch {
+	case e.ExistingName == e.Name && e.ExistingCode != e.Code:
+		_, _ = fmt.Fprintf(&sb, "name %q is registered as code %d by %s", e.ExistingName, int(e.ExistingCode), e.ExistingOwner)
+	case e.ExistingCode == e.Code && e.ExistingName != "":
+		_, _ = fmt.Fprintf(&sb, "code %d is registered as %q by %s", int(e.ExistingCode), e.ExistingName, e.ExistingOwner)
+	default:
+		_, _ = fmt.Fprintf(&sb, "code %d is in the range reserved by %s", int(e.Code), e.ExistingOwner)
+	}
+	return sb.String()
+}
+
+// Is reports a CodeConflictError is an AlreadyExists.
+func (e *CodeConflictError) Is(target error) bool {
+	c, ok := target.(Code)
+	return ok && c == AlreadyExists
+}
+
+// CodeNamespace is a range of Codes below MinErrorCode reserved by a
+// library. The codes in the range can be registered through the
+// namespace only.
+//
+//	var ns, _ = errors.ReserveCodes("github.com/me/mylib", 100, 50) // -1100 .. -1149
+//	var ErrNotReady, _ = ns.Register(0, "NOT_READY")                 // -1100
+type CodeNamespace struct {
+	name  string
+	first Code // the code nearest to zero
+	count int
+}
+
+// Name returns the name of the namespace.
+func (ns *CodeNamespace) Name() string { return ns.name }
+
+// Code returns the n-th code in the namespace.
+func (ns *CodeNamespace) Code(n int) Code { return ns.first - Code(n) }
+
+// Contains tests whether c is in the range of the namespace.
+func (ns *CodeNamespace) Contains(c Code) bool {
+	return c <= ns.first && c > ns.first-Code(ns.count)
+}
+
+// Register registers the n-th code of the namespace with codeName,
+// and the optional CodeInfo.
+func (ns *CodeNamespace) Register(n int, codeName string, info ...CodeInfo) (Code, error) {
+	if n < 0 || n >= ns.count {
+		return AlreadyExists, fmt.Errorf("errors: code index %d is out of the namespace %q [0, %d)", n, ns.name, ns.count) //nolint:goerr113
+	}
+	return registry.register(ns.Code(n), codeName, ns.name, info...)
+}
+
+type codeRegistry struct {
+	sync.RWMutex
+	frozen     bool
+	owners     map[Code]string
+	namespaces []*CodeNamespace
+}
+
+var registry = codeRegistry{owners: make(map[Code]string)}
+
+// ownerOf returns who registered c, the lock must be held.
+func (r *codeRegistry) ownerOf(c Code) string {
+	if o, ok := r.owners[c]; ok {
+		return o
+	}
+	return builtinOwner
+}
+
+// namespaceOf returns the namespace containing c, the lock must be held.
+func (r *codeRegistry) namespaceOf(c Code) *CodeNamespace {
+	for _, ns := range r.namespaces {
+		if ns.Contains(c) {
+			return ns
+		}
+	}
+	return nil
+}
+
+func (r *codeRegistry) register(c Code, codeName, owner string, infos ...CodeInfo) (Code, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.frozen {
+		return AlreadyExists, ErrRegistryFrozen
+	}
+
+	conflict := &CodeConflictError{Code: c, Name: codeName, Owner: owner}
+	if c > MinErrorCode && c <= 0 {
+		conflict.ExistingOwner = builtinOwner
+		return AlreadyExists, conflict
+	}
+	if v, ok := strToCode[codeName]; ok {
+		if v == c && r.ownerOf(v) == owner {
+			r.setInfo(c, codeName, infos) // registered already, update the info only
+			return c, nil
+		}
+		conflict.ExistingCode, conflict.ExistingName, conflict.ExistingOwner = v, codeName, r.ownerOf(v)
+		return v, conflict
+	}
+	if name, ok := codeToStr[c]; ok {
+		conflict.ExistingCode, conflict.ExistingName, conflict.ExistingOwner = c, name, r.ownerOf(c)
+		return AlreadyExists, conflict
+	}
+	if ns := r.namespaceOf(c); ns != nil && ns.name != owner {
+		conflict.ExistingOwner = ns.name
+		return AlreadyExists, conflict
+	}
+
+	strToCode[codeName] = c
+	codeToStr[c] = codeName
+	r.owners[c] = owner
+	r.setInfo(c, codeName, infos)
+	return c, nil
+}
+
+// TryRegisterCode is like RegisterCode, but it returns a detailed
+// error if the code cannot be registered. The error is either a
+// *CodeConflictError or ErrRegistryFrozen.
+//
+// Registering the same name with the same code twice by the same
+// package is not an error, the CodeInfo will be updated if given. By
+// another package, it is a *CodeConflictError.
+func TryRegisterCode(codePositive int, codeName string, info ...CodeInfo) (Code, error) {
+	return tryRegisterCode(codePositive, codeName, callerPackage(1), info...)
+}
+
+func tryRegisterCode(codePositive int, codeName, owner string, infos ...CodeInfo) (Code, error) {
+	c := Code(codePositive)
+	if codePositive > 0 {
+		c = MinErrorCode - Code(codePositive)
+	}
+	return registry.register(c, codeName, owner, infos...)
+}
+
+// ReserveCodes reserves count codes for the namespace name, from
+// MinErrorCode-first down to MinErrorCode-first-count+1.
+//
+// A namespace is typically a library, so it can register its codes
+// without worrying about conflicts with the others.
+func ReserveCodes(name string, first, count int) (*CodeNamespace, error) {
+	if first <= 0 || count <= 0 {
+		return nil, fmt.Errorf("errors: invalid code range [%d, +%d) for namespace %q", first, count, name) //nolint:goerr113
+	}
+
+	ns := &CodeNamespace{name: name, first: MinErrorCode - Code(first), count: count}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.frozen {
+		return nil, ErrRegistryFrozen
+	}
+	for _, x := range registry.namespaces {
+		if x.name == name {
+			if x.first == ns.first && x.count == ns.count {
+				return x, nil
+			}
+			return nil, fmt.Errorf("errors: namespace %q is reserved with another range", name) //nolint:goerr113
+		}
+		if x.Contains(ns.first) || x.Contains(ns.Code(count-1)) || ns.Contains(x.first) {
+			return nil, &CodeConflictError{Code: ns.first, Name: name, Owner: name, ExistingOwner: x.name}
+		}
+	}
+	for c, codeName := range codeToStr {
+		if ns.Contains(c) {
+			return nil, &CodeConflictError{Code: c, Name: name, Owner: name,
+				ExistingCode: c, ExistingName: codeName, ExistingOwner: registry.ownerOf(c)}
+		}
+	}
+	registry.namespaces = append(registry.namespaces, ns)
+	return ns, nil
+}
+
+// Freeze stops the registering of Codes and namespaces, any
+// later registering fails with ErrRegistryFrozen.
+//
+// It is typically called once the application has started, so that
+// a plugin loaded later cannot hijack the codes.
+func Freeze() {
+	registry.Lock()
+	registry.frozen = true
+	registry.Unlock()
+}
+
+// CodeOwner returns the package or namespace who registered c, or
+// an empty string if c is not registered.
+func CodeOwner(c Code) string {
+	registry.RLock()
+	defer registry.RUnlock()
+	if _, ok := codeToStr[c]; !ok {
+		return ""
+	}
+	return registry.ownerOf(c)
+}
+
+// callerPackage returns the package path of the caller, skip is
+// the number of frames to skip, 0 identifies the caller of
+// callerPackage.
+func callerPackage(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+	return packageName(fn.Name())
+}
+
+// packageName extracts the package path from a function's name
+// reported by func.Name(). The escaped dots in the path, such as
+// "errors%2ev3", are restored.
+func packageName(name string) string {
+	i := strings.LastIndex(name, "/")
+	if j := strings.Index(name[i+1:], "."); j >= 0 {
+		name = name[:i+1+j]
+	}
+	return strings.Replace(name, "%2e", ".", -1) //nolint:gocritic // go1.11 has no ReplaceAll
+}
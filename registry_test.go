@@ -0,0 +1,128 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTryRegisterCode(t *testing.T) {
+	c, err := TryRegisterCode(701, "REG_TEST_701")
+	if err != nil || c != MinErrorCode-701 {
+		t.Fatalf("TryRegisterCode failed: %v, %v", c, err)
+	}
+	if c1, err := TryRegisterCode(701, "REG_TEST_701"); err != nil || c1 != c {
+		t.Fatalf("registering twice should be ok: %v, %v", c1, err)
+	}
+	if owner := CodeOwner(c); owner != builtinOwner {
+		t.Fatalf("unexpected owner %q", owner)
+	}
+
+	_, err = TryRegisterCode(702, "REG_TEST_701")
+	var ce *CodeConflictError
+	if !As(err, &ce) || ce.ExistingCode != c || !Is(err, AlreadyExists) {
+		t.Fatalf("expecting a name conflict, but got %v", err)
+	}
+	t.Log(err)
+
+	_, err = TryRegisterCode(701, "REG_TEST_701_B")
+	if !As(err, &ce) || ce.ExistingName != "REG_TEST_701" || !strings.Contains(err.Error(), "REG_TEST_701") {
+		t.Fatalf("expecting a number conflict, but got %v", err)
+	}
+	t.Log(err)
+
+	if _, err = TryRegisterCode(-13, "MY_INTERNAL"); !Is(err, AlreadyExists) {
+		t.Fatalf("expecting builtin range conflict, but got %v", err)
+	}
+
+	// legacy behaviors
+	if c1 := RegisterCode(703, "REG_TEST_701"); c1 != c {
+		t.Fatalf("RegisterCode should return the existing code, but got %v", c1)
+	}
+	if c1 := RegisterCode(701, "REG_TEST_701_C"); c1 != AlreadyExists {
+		t.Fatalf("RegisterCode should return AlreadyExists, but got %v", c1)
+	}
+}
+
+func TestRegisterCodeOwner(t *testing.T) {
+	c, err := tryRegisterCode(704, "REG_TEST_704", "example.com/a", CodeInfo{HTTPStatus: 418})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tryRegisterCode(704, "REG_TEST_704", "example.com/a", CodeInfo{HTTPStatus: 429}); err != nil || c.HTTPStatus() != 429 {
+		t.Fatalf("the owner should update the info: %v, %d", err, c.HTTPStatus())
+	}
+
+	_, err = tryRegisterCode(704, "REG_TEST_704", "example.com/b", CodeInfo{HTTPStatus: 500})
+	var ce *CodeConflictError
+	if !As(err, &ce) || ce.ExistingOwner != "example.com/a" || ce.Owner != "example.com/b" {
+		t.Fatalf("expecting an owner conflict, but got %v", err)
+	}
+	if c.HTTPStatus() != 429 {
+		t.Fatalf("the info should not be overwritten by another owner: %d", c.HTTPStatus())
+	}
+}
+
+func TestReserveCodes(t *testing.T) {
+	ns, err := ReserveCodes("example.com/lib1", 800, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns1, err := ReserveCodes("example.com/lib1", 800, 10); err != nil || ns1 != ns {
+		t.Fatalf("reserving twice should be ok: %v", err)
+	}
+	if _, err = ReserveCodes("example.com/lib2", 805, 10); !Is(err, AlreadyExists) {
+		t.Fatalf("expecting overlapped range conflict, but got %v", err)
+	}
+	t.Log(err)
+
+	c, err := ns.Register(3, "LIB1_NOT_READY")
+	if err != nil || c != MinErrorCode-803 || CodeOwner(c) != "example.com/lib1" {
+		t.Fatalf("ns.Register failed: %v, %v", c, err)
+	}
+	if _, err = ns.Register(10, "LIB1_OUT"); err == nil {
+		t.Fatal("expecting out of range error")
+	}
+	if _, err = TryRegisterCode(804, "NOT_LIB1"); !Is(err, AlreadyExists) {
+		t.Fatalf("expecting reserved range conflict, but got %v", err)
+	}
+	t.Log(err)
+}
+
+func TestRegisterCodeConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := RegisterCode(900+i%4, fmt.Sprintf("REG_PARALLEL_%d", i%4))
+			_ = c.String()
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 4; i++ {
+		if c := MinErrorCode - Code(900+i); c.String() != fmt.Sprintf("REG_PARALLEL_%d", i) {
+			t.Fatalf("unexpected %v", c)
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	defer func() {
+		registry.Lock()
+		registry.frozen = false
+		registry.Unlock()
+	}()
+
+	Freeze()
+	if _, err := TryRegisterCode(950, "REG_FROZEN"); err != ErrRegistryFrozen { //nolint:errorlint
+		t.Fatalf("expecting ErrRegistryFrozen, but got %v", err)
+	}
+	if _, err := ReserveCodes("example.com/frozen", 960, 1); err != ErrRegistryFrozen { //nolint:errorlint
+		t.Fatalf("expecting ErrRegistryFrozen, but got %v", err)
+	}
+	if c := RegisterCode(950, "REG_FROZEN"); c != AlreadyExists {
+		t.Fatalf("expecting AlreadyExists, but got %v", c)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+)
+
+func init() { //nolint:gochecknoinits
+	gob.Register(&WithStackInfo{})
+	gob.Register(Code(0))
+}
+
+// RemoteFrame is a stack frame decoded from the textual form, it
+// comes from another process so it has no program counter.
+type RemoteFrame struct {
+	Function string // the full name of the function, with the package path
+	File     string // the full path of the source file
+	Line     int
+}
+
+// Format formats the frame like Frame.Format does.
+//
+//	%s    source file
+//	%d    source line
+//	%n    function name
+//	%v    equivalent to %s:%d
+//
+// Format accepts flags that alter the printing of some verbs, as follows:
+//
+//	%+s   function name and path of source file (<funcname>\n\t<path>)
+//	%+v   equivalent to %+s:%d
+func (f RemoteFrame) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 's':
+		if s.Flag('+') {
+			_, _ = fmt.Fprintf(s, "%s\n\t%s", f.Function, f.File)
+		} else {
+			_, _ = io.WriteString(s, path.Base(f.File))
+		}
+	case 'd':
+		_, _ = fmt.Fprintf(s, "%d", f.Line)
+	case 'n':
+		_, _ = io.WriteString(s, funcname(f.Function))
+	case 'v':
+		f.Format(s, 's')
+		_, _ = io.WriteString(s, ":")
+		f.Format(s, 'd')
+	}
+}
+
+// RemoteStack is the stack of a decoded error, from innermost
+// (newest) to outermost (oldest).
+type RemoteStack []RemoteFrame
+
+// Format formats the stack like Stack.Format does, %+v prints the
+// function, file name and line number for each frame.
+func (rs RemoteStack) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		for _, f := range rs {
+			_, _ = fmt.Fprintf(s, "\n%+v", f)
+		}
+	}
+}
+
+// RemoteStack returns the stack decoded with w, it is empty if w was
+// not decoded.
+func (w *WithStackInfo) RemoteStack() RemoteStack { return w.remote }
+
+// UnmarshalJSONError decodes an error tree encoded by MarshalJSON or
+// MarshalJSONWith.
+//
+// The errors of the tree are rebuilt as *WithStackInfo with their
+// Code, message, causes, Data and TaggedData, and the stack frames
+// as a RemoteStack. A Code is rebuilt as itself, found by its name
+// first. A registered sentinel (see RegisterSentinel) is substituted
+// by the sentinel error. So Is works on the decoded error as on the
+// original one:
+//
+//	err, _ := errors.UnmarshalJSONError(data)
+//	if errors.Is(err, errors.NotFound) {
+//	    ...
+//	}
+//
+// A JSON null is decoded as a nil error.
+func UnmarshalJSONError(data []byte) (err, e error) { //nolint:revive
+	var je *jsonError
+	if e = json.Unmarshal(data, &je); e != nil || je == nil {
+		return
+	}
+	err = fromJSONError(je)
+	return
+}
+
+// UnmarshalJSON decodes an error tree encoded by MarshalJSON into w,
+// see UnmarshalJSONError. If the root is a Code or a sentinel, it is
+// attached into w.
+func (w *WithStackInfo) UnmarshalJSON(data []byte) error {
+	err, e := UnmarshalJSONError(data)
+	if e != nil {
+		return e
+	}
+	switch x := err.(type) {
+	case nil:
+		*w = WithStackInfo{}
+	case *WithStackInfo:
+		*w = *x
+	default:
+		*w = WithStackInfo{causes2: causes2{Causers: []error{x}}}
+	}
+	return nil
+}
+
+// GobEncode encodes w in the JSON form with the stack frames, so
+// that a *WithStackInfo can be sent by encoding/gob.
+func (w *WithStackInfo) GobEncode() ([]byte, error) {
+	return MarshalJSONWith(w, JSONOmitStack(false))
+}
+
+// GobDecode decodes w encoded by GobEncode.
+func (w *WithStackInfo) GobDecode(data []byte) error {
+	return w.UnmarshalJSON(data)
+}
+
+func fromJSONError(je *jsonError) error {
+	if je.Sentinel != "" {
+		if s, ok := LookupSentinel(je.Sentinel); ok {
+			return s
+		}
+	}
+	if je.Type == codeTypeName && je.Code != nil {
+		return codeFromJSON(je.Code)
+	}
+
+	w := &WithStackInfo{causes2: causes2{msg: je.Message, msgCauses: je.MessageCauses, wrapsCause: je.MessageWraps}}
+	if je.Type != withStackInfoTypeName {
+		w.remoteType = je.Type
+	}
+	if je.Code != nil {
+		w.Code = codeFromJSON(je.Code)
+	}
+	for _, c := range je.Causes {
+		if c != nil {
+			w.Causers = append(w.Causers, fromJSONError(c))
+		}
+	}
+	for _, raw := range je.Data {
+		var v interface{} //nolint:revive
+		_ = json.Unmarshal(raw, &v)
+		w.sites = append(w.sites, v)
+	}
+	if len(je.TaggedData) > 0 {
+		w.taggedSites = make(TaggedData, len(je.TaggedData))
+		for k, raw := range je.TaggedData {
+			var v interface{} //nolint:revive
+			_ = json.Unmarshal(raw, &v)
+			w.taggedSites[k] = v
+		}
+	}
+	for _, f := range je.Stack {
+		w.remote = append(w.remote, RemoteFrame(f))
+	}
+	w.omitted = je.StackOmitted
+	return w
+}
+
+func codeFromJSON(jc *jsonCode) Code {
+	if c, ok := LookupCode(jc.Name); ok {
+		return c
+	}
+	return Code(jc.Number)
+}
@@ -0,0 +1,83 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+var errQuota = New("quota exceeded")
+
+func init() { _ = RegisterSentinel("errors.errQuota", errQuota) }
+
+func TestUnmarshalJSONError(t *testing.T) {
+	orig := Wrap(New("save %q", "a.txt").
+		WithErrors(errQuota, fmt.Errorf("flush: %w", io.ErrShortWrite)).
+		WithCode(NotFound).
+		WithData(42).
+		WithTaggedData(TaggedData{"user": "bob"}), "handler")
+
+	data, err := MarshalJSONWith(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := UnmarshalJSONError(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", back)
+
+	if back.Error() != orig.Error() {
+		t.Fatalf("bad message:\n got %q\nwant %q", back.Error(), orig.Error())
+	}
+	if !Is(back, NotFound) || !Is(back, errQuota) || Is(back, Internal) || Is(back, io.EOF) {
+		t.Fatal("bad Is on the decoded error")
+	}
+
+	inner, ok := Causes(back)[0].(*WithStackInfo)
+	if !ok || len(inner.Data()) != 1 || inner.TaggedData()["user"] != "bob" {
+		t.Fatalf("bad inner error: %+v", Causes(back))
+	}
+	rs := inner.RemoteStack()
+	if len(rs) == 0 || !strings.HasSuffix(rs[0].Function, ".TestUnmarshalJSONError") {
+		t.Fatalf("bad remote stack: %v", rs)
+	}
+	if s := fmt.Sprintf("%+v", back); !strings.Contains(s, "remote_test.go:") {
+		t.Fatalf("remote stack not printed: %s", s)
+	}
+	if s := fmt.Sprintf("%v|%n", rs[0], rs[0]); s != fmt.Sprintf("remote_test.go:%d|TestUnmarshalJSONError", rs[0].Line) {
+		t.Fatalf("bad frame format: %s", s)
+	}
+
+	// encoding again keeps the original type and stack
+	again, _ := MarshalJSONWith(back)
+	if !bytes.Equal(again, data) {
+		t.Fatalf("not stable:\n%s\n%s", data, again)
+	}
+
+	if c, _ := UnmarshalJSONError([]byte(`{"type":"errors.Code","message":"X","code":{"name":"DEADLINE_EXCEEDED","number":0}}`)); c != DeadlineExceeded {
+		t.Fatalf("bad code: %v", c)
+	}
+	if e, err := UnmarshalJSONError([]byte(`null`)); e != nil || err != nil {
+		t.Fatalf("bad null: %v, %v", e, err)
+	}
+}
+
+func TestGob(t *testing.T) {
+	var orig error = Internal.New("boom").WithErrors(io.EOF)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&orig); err != nil {
+		t.Fatal(err)
+	}
+	var back error
+	if err := gob.NewDecoder(&buf).Decode(&back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Error() != orig.Error() || !Is(back, Internal) {
+		t.Fatalf("bad decoded error: %v", back)
+	}
+}
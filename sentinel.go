@@ -0,0 +1,148 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// sentinelRegistry maps the names to the sentinel errors and back.
+type sentinelRegistry struct {
+	sync.RWMutex
+	byName map[string]error
+	names  map[error]string // the canonical names
+}
+
+var sentinels = sentinelRegistry{
+	byName: make(map[string]error),
+	names:  make(map[error]string),
+}
+
+func init() { //nolint:gochecknoinits
+	for _, s := range builtinSentinels {
+		_ = RegisterSentinel(s.name, s.err)
+	}
+}
+
+// builtinSentinels lists the sentinels of the standard library. The
+// name is the import path and the variable name, such as "io.EOF".
+//
+// The sentinels of net/http are registered by the subpackage httperr,
+// to keep net/http out of the dependencies of this package.
+var builtinSentinels = []struct {
+	name string
+	err  error
+}{
+	{"io.EOF", io.EOF},
+	{"io.ErrClosedPipe", io.ErrClosedPipe},
+	{"io.ErrNoProgress", io.ErrNoProgress},
+	{"io.ErrShortBuffer", io.ErrShortBuffer},
+	{"io.ErrShortWrite", io.ErrShortWrite},
+	{"io.ErrUnexpectedEOF", io.ErrUnexpectedEOF},
+
+	{"os.ErrInvalid", os.ErrInvalid},
+	{"os.ErrPermission", os.ErrPermission},
+	{"os.ErrExist", os.ErrExist},
+	{"os.ErrNotExist", os.ErrNotExist},
+	{"os.ErrClosed", os.ErrClosed},
+	{"os.ErrNoDeadline", os.ErrNoDeadline},
+
+	{"context.Canceled", context.Canceled},
+	{"context.DeadlineExceeded", context.DeadlineExceeded},
+}
+
+// RegisterSentinel registers a sentinel error with a stable name, so
+// that it can be identified after being encoded and decoded.
+//
+// The JSON, gob and problem details encodings write the name of a
+// registered sentinel, and the decoders substitute the sentinel for
+// it. So Is(err, target) works on an error decoded in another
+// process, as long as both processes registered the same name:
+//
+//	var ErrQuota = errors.New("quota exceeded")
+//
+//	func init() { _ = errors.RegisterSentinel("github.com/me/mylib.ErrQuota", ErrQuota) }
+//
+// The name is conventionally the import path and the variable name.
+// The sentinels of io, os, context and io/fs are registered already,
+// and the ones of net/http are registered by importing httperr.
+//
+// Registering the same error with the same name again is not an
+// error, but a name or an error can be registered once only. Use
+// RegisterSentinelAlias to give an error more names.
+func RegisterSentinel(name string, err error) error {
+	if name == "" || err == nil || !reflect.TypeOf(err).Comparable() {
+		return fmt.Errorf("errors: cannot register sentinel %q: a name and a comparable error required", name) //nolint:goerr113
+	}
+
+	sentinels.Lock()
+	defer sentinels.Unlock()
+
+	if e, ok := sentinels.byName[name]; ok {
+		if e == err { //nolint:errorlint
+			return nil
+		}
+		return fmt.Errorf("errors: sentinel name %q is registered already", name) //nolint:goerr113
+	}
+	if n, ok := sentinels.names[err]; ok {
+		return fmt.Errorf("errors: sentinel %q is registered as %q already", name, n) //nolint:goerr113
+	}
+	sentinels.byName[name] = err
+	sentinels.names[err] = name
+	return nil
+}
+
+// RegisterSentinelAlias registers alias as another name of the
+// sentinel registered with name, such as fs.ErrNotExist which is the
+// same error as os.ErrNotExist.
+//
+// The decoders recognize the alias, but the encoders write the name
+// given to RegisterSentinel still. Registering the same alias again
+// is not an error.
+func RegisterSentinelAlias(alias, name string) error {
+	if alias == "" {
+		return fmt.Errorf("errors: cannot register sentinel alias of %q: a name required", name) //nolint:goerr113
+	}
+
+	sentinels.Lock()
+	defer sentinels.Unlock()
+
+	err, ok := sentinels.byName[name]
+	if !ok {
+		return fmt.Errorf("errors: cannot register sentinel alias %q: %q is not registered", alias, name) //nolint:goerr113
+	}
+	if e, ok := sentinels.byName[alias]; ok {
+		if e == err { //nolint:errorlint
+			return nil
+		}
+		return fmt.Errorf("errors: sentinel name %q is registered already", alias) //nolint:goerr113
+	}
+	sentinels.byName[alias] = err
+	return nil
+}
+
+// SentinelName returns the canonical name of err if it is a
+// registered sentinel.
+func SentinelName(err error) (name string, ok bool) {
+	if err == nil || !reflect.TypeOf(err).Comparable() {
+		return
+	}
+	sentinels.RLock()
+	defer sentinels.RUnlock()
+	name, ok = sentinels.names[err]
+	return
+}
+
+// LookupSentinel returns the sentinel error registered with name or
+// alias.
+func LookupSentinel(name string) (err error, ok bool) { //nolint:revive,stylecheck
+	sentinels.RLock()
+	defer sentinels.RUnlock()
+	err, ok = sentinels.byName[name]
+	return
+}
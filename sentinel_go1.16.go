@@ -0,0 +1,20 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.16
+// +build go1.16
+
+package errors
+
+import (
+	"os"
+)
+
+func init() { //nolint:gochecknoinits
+	_ = RegisterSentinel("os.ErrDeadlineExceeded", os.ErrDeadlineExceeded)
+	_ = RegisterSentinel("os.ErrProcessDone", os.ErrProcessDone)
+
+	// os.ErrXXX are the same errors as fs.ErrXXX
+	for _, name := range []string{"ErrInvalid", "ErrPermission", "ErrExist", "ErrNotExist", "ErrClosed"} {
+		_ = RegisterSentinelAlias("io/fs."+name, "os."+name)
+	}
+}
@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRegisterSentinel(t *testing.T) {
+	errA := New("a")
+	if err := RegisterSentinel("test.errA", errA); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterSentinel("test.errA", errA); err != nil {
+		t.Fatalf("registering again: %v", err)
+	}
+	if err := RegisterSentinel("test.errA", io.ErrNoProgress); err == nil {
+		t.Fatal("expecting name conflict")
+	}
+	if err := RegisterSentinel("test.errA2", errA); err == nil {
+		t.Fatal("expecting sentinel conflict")
+	}
+	if err := RegisterSentinel("", io.ErrNoProgress); err == nil {
+		t.Fatal("expecting empty name rejected")
+	}
+
+	if name, ok := SentinelName(errA); !ok || name != "test.errA" {
+		t.Fatalf("bad name: %q", name)
+	}
+	if e, ok := LookupSentinel("test.errA"); !ok || e != errA {
+		t.Fatalf("bad lookup: %v", e)
+	}
+	if _, ok := SentinelName(New("b")); ok {
+		t.Fatal("unexpected name")
+	}
+}
+
+func TestRegisterSentinelAlias(t *testing.T) {
+	errB := New("b")
+	if err := RegisterSentinel("test.errB", errB); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterSentinelAlias("test.errB2", "test.errB"); err != nil {
+		t.Fatalf("registering an alias: %v", err)
+	}
+	if err := RegisterSentinelAlias("test.errB2", "test.errB"); err != nil {
+		t.Fatalf("registering again: %v", err)
+	}
+	if err := RegisterSentinelAlias("test.errB3", "test.none"); err == nil {
+		t.Fatal("expecting unknown name rejected")
+	}
+	if err := RegisterSentinelAlias("io.EOF", "test.errB"); err == nil {
+		t.Fatal("expecting name conflict")
+	}
+	if err := RegisterSentinelAlias("", "test.errB"); err == nil {
+		t.Fatal("expecting empty alias rejected")
+	}
+
+	if e, ok := LookupSentinel("test.errB2"); !ok || e != errB {
+		t.Fatalf("bad alias lookup: %v", e)
+	}
+	if name, _ := SentinelName(errB); name != "test.errB" {
+		t.Fatalf("the alias should not be the canonical name: %q", name)
+	}
+}
+
+func TestSentinelRoundTrip(t *testing.T) {
+	for _, s := range builtinSentinels {
+		data, err := MarshalJSONWith(Wrap(s.err, "failed"), JSONOmitStack(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := UnmarshalJSONError(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Is(back, s.err) || !IsAnyOf(back, io.ErrNoProgress, s.err) || !Iss(back, s.err) {
+			t.Errorf("%s: decoded %s does not match", s.name, data)
+		}
+		if name, _ := SentinelName(s.err); name != s.name {
+			t.Errorf("%s: bad canonical name %q", s.name, name)
+		}
+	}
+
+	if e, ok := LookupSentinel("io/fs.ErrNotExist"); ok && e != os.ErrNotExist {
+		t.Fatal("fs.ErrNotExist should be an alias of os.ErrNotExist")
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+)
+
+// LogValue implements slog.LogValuer, it logs w as a group with the
+// message, Code, causes, Data and TaggedData. The stack is not
+// included, see SlogHandler.
+//
+//	slog.Error("save failed", "err", err)
+//	// level=ERROR msg="save failed" err.msg=... err.code.name=NOT_FOUND err.code.number=-5 err.causes.0=EOF
+func (w *WithStackInfo) LogValue() slog.Value {
+	return errorLogValue(w, make(visitedSet))
+}
+
+// LogValue implements slog.LogValuer, see WithStackInfo.LogValue.
+func (w *causes2) LogValue() slog.Value {
+	return errorLogValue(w, make(visitedSet))
+}
+
+// LogValue implements slog.LogValuer, it logs c as a group with the
+// name and number.
+func (c Code) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("name", c.String()),
+		slog.Int("number", int(c)),
+	)
+}
+
+// errorLogValue returns the slog value of err and its inner errors.
+// A plain error without inner errors is logged as its message.
+func errorLogValue(err error, ancestors visitedSet) slog.Value {
+	if !ancestors.enter(err, nil) {
+		return slog.StringValue(cycleMarker)
+	}
+	defer ancestors.leave(err, nil)
+
+	var attrs []slog.Attr
+	var causes []error
+	switch e := err.(type) {
+	case Code:
+		return e.LogValue()
+	case interface{ self() *causes2 }:
+		c := e.self()
+		if msg := c.message(); msg != "" {
+			attrs = append(attrs, slog.String("msg", msg))
+		}
+		if c.Code != OK {
+			attrs = append(attrs, slog.Any("code", c.Code))
+		}
+		causes = c.Causers
+	default:
+		causes = Children(err)
+		if len(causes) == 0 {
+			return slog.StringValue(err.Error())
+		}
+		attrs = append(attrs, slog.String("msg", err.Error()))
+	}
+
+	if len(causes) > 0 {
+		ca := make([]slog.Attr, 0, len(causes))
+		for i, c := range causes {
+			if c != nil {
+				ca = append(ca, slog.Attr{Key: strconv.Itoa(i), Value: errorLogValue(c, ancestors)})
+			}
+		}
+		attrs = append(attrs, slog.Attr{Key: "causes", Value: slog.GroupValue(ca...)})
+	}
+	if w, ok := err.(*WithStackInfo); ok {
+		if len(w.sites) > 0 {
+			attrs = append(attrs, slog.Any("data", w.sites))
+		}
+		if len(w.taggedSites) > 0 {
+			ta := make([]slog.Attr, 0, len(w.taggedSites))
+			for k, v := range w.taggedSites {
+				ta = append(ta, slog.Any(k, v))
+			}
+			attrs = append(attrs, slog.Attr{Key: "tagged", Value: slog.GroupValue(ta...)})
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// SlogHandlerOptions are the options of SlogHandler.
+type SlogHandlerOptions struct {
+	// Stack adds the stack of the logged error as an attribute, once
+	// per record. If several errors are logged in a record, the first
+	// one with a stack is used.
+	Stack bool
+	// StackKey is the key of the stack attribute, "stack" by default.
+	StackKey string
+}
+
+// SlogHandler is a slog.Handler middleware which expands the error
+// attributes as groups (see WithStackInfo.LogValue), and optionally
+// adds the stack of the error.
+//
+//	logger := slog.New(errors.NewSlogHandler(slog.NewJSONHandler(os.Stderr, nil),
+//	    &errors.SlogHandlerOptions{Stack: true}))
+//	logger.Error("save failed", "err", err)
+//
+// Any error is expanded, including the ones which are not made by
+// this package.
+type SlogHandler struct {
+	next slog.Handler
+	opts SlogHandlerOptions
+}
+
+// NewSlogHandler returns a SlogHandler which passes the records to
+// next. opts can be nil.
+func NewSlogHandler(next slog.Handler, opts *SlogHandlerOptions) *SlogHandler {
+	h := &SlogHandler{next: next}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.StackKey == "" {
+		h.opts.StackKey = "stack"
+	}
+	return h
+}
+
+// Enabled implements slog.Handler.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler, it expands the error attributes of
+// r before passing it to the next handler.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var stack []string
+	var stackFound bool
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(expandAttr(a, func(err error) {
+			if h.opts.Stack && !stackFound {
+				stack, stackFound = logStack(err)
+			}
+		}))
+		return true
+	})
+	if stackFound {
+		nr.AddAttrs(slog.Any(h.opts.StackKey, stack))
+	}
+	return h.next.Handle(ctx, nr)
+}
+
+// WithAttrs implements slog.Handler, the error attributes are
+// expanded without their stacks.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		expanded[i] = expandAttr(a, func(error) {})
+	}
+	return &SlogHandler{next: h.next.WithAttrs(expanded), opts: h.opts}
+}
+
+// WithGroup implements slog.Handler.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	return &SlogHandler{next: h.next.WithGroup(name), opts: h.opts}
+}
+
+// expandAttr expands the errors in a, and calls found for each of
+// them.
+func expandAttr(a slog.Attr, found func(err error)) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindAny, slog.KindLogValuer:
+		if err, ok := a.Value.Any().(error); ok && err != nil {
+			found(err)
+			return slog.Attr{Key: a.Key, Value: errorLogValue(err, make(visitedSet))}
+		}
+	case slog.KindGroup:
+		group := a.Value.Group()
+		expanded := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			expanded[i] = expandAttr(ga, found)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(expanded...)}
+	}
+	return a
+}
+
+// logStack returns the stack of the deepest WithStackInfo in err's
+// tree, which is nearest to where the failure happened, as lines of
+// "function file:line".
+func logStack(err error) (lines []string, ok bool) {
+	var deepest *WithStackInfo
+	maxDepth := -1
+	_ = Walk(err, func(e error, depth int, path []int, parent error) error {
+		if w, yes := e.(*WithStackInfo); yes && depth > maxDepth && (w.Stack != nil || len(w.remote) > 0) {
+			deepest, maxDepth = w, depth
+		}
+		return nil
+	})
+	if deepest == nil {
+		return nil, false
+	}
+	for _, f := range deepest.Frames() {
+		lines = append(lines, f.Function+" "+f.File+":"+strconv.Itoa(f.Line))
+	}
+	for _, f := range deepest.remote {
+		lines = append(lines, f.Function+" "+f.File+":"+strconv.Itoa(f.Line))
+	}
+	return lines, true
+}
@@ -0,0 +1,94 @@
+// Copyright © 2023 Hedzr Yeh.
+
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+)
+
+func TestLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: dropTime}))
+
+	err := New("save %q", "a.txt").WithErrors(io.EOF).WithCode(NotFound).WithTaggedData(TaggedData{"user": "bob"})
+	logger.Error("failed", "err", err, "code", Internal)
+
+	want := `level=ERROR msg=failed err.msg="save \"a.txt\"" err.code.name=NOT_FOUND err.code.number=-5 err.causes.0=EOF err.tagged.user=bob code.name=INTERNAL code.number=-13` + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("bad log:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSlogHandler(slog.NewJSONHandler(&buf, nil), &SlogHandlerOptions{Stack: true})
+	logger := slog.New(h).With("base", io.ErrUnexpectedEOF)
+
+	inner := New("inner").WithCode(Internal)
+	logger.Error("failed",
+		"err", Wrap(inner, "outer"),
+		slog.Group("g", "plain", io.EOF),
+		"other", New("other"))
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%s", buf.Bytes())
+
+	if m["base"] != "unexpected EOF" || m["g"].(map[string]interface{})["plain"] != "EOF" {
+		t.Fatalf("bad plain errors: %v", m)
+	}
+	e := m["err"].(map[string]interface{})
+	ie := e["causes"].(map[string]interface{})["0"].(map[string]interface{})
+	if e["msg"] != "outer" || ie["code"].(map[string]interface{})["name"] != "INTERNAL" {
+		t.Fatalf("bad err: %v", e)
+	}
+	stack, _ := m["stack"].([]interface{})
+	if len(stack) == 0 || !strings.Contains(stack[0].(string), ".TestSlogHandler ") ||
+		!strings.Contains(stack[0].(string), "slog_go1.21_test.go:") {
+		t.Fatalf("bad stack: %v", m["stack"])
+	}
+	if strings.Count(buf.String(), `"stack"`) != 1 {
+		t.Fatal("the stack should be logged once")
+	}
+}
+
+func TestSlogHandlerConformance(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSlogHandler(slog.NewJSONHandler(&buf, nil), &SlogHandlerOptions{Stack: true})
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatal(err)
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func dropTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+	return a
+}
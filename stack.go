@@ -6,36 +6,29 @@ import (
 	"path"
 	"runtime"
 	"strings"
+	"sync/atomic"
 )
 
 // Frame represents a program counter inside a Stack frame.
 type Frame uintptr
 
-// pc returns the program counter for this frame;
-// multiple frames may have the same PC value.
-func (f Frame) pc() uintptr { return uintptr(f) - 1 }
+// Resolve returns the frame resolved by runtime.CallersFrames. If the
+// pc holds inlined calls, the innermost one is returned.
+func (f Frame) Resolve() ResolvedFrame {
+	frames := resolveFrames([]uintptr{uintptr(f)})
+	return frames[0]
+}
 
 // file returns the full path to the file that contains the
 // function for this Frame's pc.
-func (f Frame) file() string {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return "unknown"
-	}
-	file, _ := fn.FileLine(f.pc())
-	return file
-}
+func (f Frame) file() string { return f.Resolve().File }
 
 // line returns the line number of source code of the
 // function for this Frame's pc.
-func (f Frame) line() int {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return 0
-	}
-	_, line := fn.FileLine(f.pc())
-	return line
-}
+func (f Frame) line() int { return f.Resolve().Line }
+
+// name returns the name of the function for this Frame's pc.
+func (f Frame) name() string { return f.Resolve().Function }
 
 // Format formats the frame according to the fmt.Formatter interface.
 //
@@ -50,27 +43,39 @@ func (f Frame) line() int {
 //	      compiling time.
 //	      GOPATH separated by \n\t (<funcname>\n\t<path>)
 //	%+v   equivalent to %+s:%d
+//
+// The path of source file is trimmed as SetPathMode specified.
 func (f Frame) Format(s fmt.State, verb rune) {
+	f.Resolve().Format(s, verb)
+}
+
+// ResolvedFrame is a stack frame resolved by runtime.CallersFrames.
+//
+// A program counter of an inlined call is resolved to several
+// frames, the inner ones are marked as Inlined.
+type ResolvedFrame struct {
+	Function string  // the function name with the package path, as reported by the runtime
+	Package  string  // the package path
+	File     string  // the full path of the source file
+	Line     int     // the line number in File
+	Entry    uintptr // the entry address of the function, or the function which it is inlined into; 0 if unknown
+	Inlined  bool    // the call has been inlined into the next frame
+}
+
+// Format formats the frame like Frame.Format does.
+func (f ResolvedFrame) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 's':
 		switch {
 		case s.Flag('+'):
-			pc := f.pc()
-			fn := runtime.FuncForPC(pc)
-			if fn == nil {
-				_, _ = io.WriteString(s, "unknown")
-			} else {
-				file, _ := fn.FileLine(pc)
-				_, _ = fmt.Fprintf(s, "%s\n\t%s", fn.Name(), file)
-			}
+			_, _ = fmt.Fprintf(s, "%s\n\t%s", f.Function, trimPath(f.File, f.Package))
 		default:
-			_, _ = io.WriteString(s, path.Base(f.file()))
+			_, _ = io.WriteString(s, path.Base(f.File))
 		}
 	case 'd':
-		_, _ = fmt.Fprintf(s, "%d", f.line())
+		_, _ = fmt.Fprintf(s, "%d", f.Line)
 	case 'n':
-		name := runtime.FuncForPC(f.pc()).Name()
-		_, _ = io.WriteString(s, funcname(name))
+		_, _ = io.WriteString(s, funcname(f.Function))
 	case 'v':
 		f.Format(s, 's')
 		_, _ = io.WriteString(s, ":")
@@ -78,6 +83,88 @@ func (f Frame) Format(s fmt.State, verb rune) {
 	}
 }
 
+// resolveFrames resolves pcs to the frames through the frame cache,
+// the inlined calls are expanded. It returns an "unknown" frame at
+// least.
+func resolveFrames(pcs []uintptr) (frames []ResolvedFrame) {
+	var last resolvedPC
+	walkPCs(pcs, func(_ frameKey, r resolvedPC) bool {
+		if n := len(frames); n > 0 && last.funcNil && len(r.frames) > 0 &&
+			r.frames[0].Entry != 0 && r.frames[0].Entry == frames[n-1].Entry {
+			frames[n-1].Inlined = true
+		}
+		frames = append(frames, r.frames...)
+		last = r
+		return true
+	})
+	if len(frames) == 0 {
+		frames = append(frames, ResolvedFrame{Function: "unknown", File: "unknown"})
+	}
+	return
+}
+
+// walkPCs resolves pcs one by one through the frame cache, and calls
+// fn with the frames of each pc until it returns false.
+func walkPCs(pcs []uintptr, fn func(k frameKey, r resolvedPC) bool) {
+	var last resolvedPC
+	for i, pc := range pcs {
+		afterSigpanic := len(last.frames) > 0 && last.frames[len(last.frames)-1].Function == "runtime.sigpanic"
+		k := frameKey{pc: pc, afterSigpanic: afterSigpanic}
+		r := frameCache.get(k, func() resolvedPC {
+			if afterSigpanic {
+				// the pc after runtime.sigpanic is the faulting
+				// instruction, it is resolved with the sigpanic pc
+				// so that the runtime does not take it as a return
+				// address.
+				r := resolvePCs(pcs[i-1 : i+1])
+				if len(r.frames) > 0 {
+					r.frames = r.frames[1:]
+				}
+				return r
+			}
+			return resolvePCs(pcs[i : i+1])
+		})
+		if !fn(k, r) {
+			return
+		}
+		last = r
+	}
+}
+
+// resolvedPC holds the frames resolved from a pc.
+type resolvedPC struct {
+	frames  []ResolvedFrame
+	funcNil bool // the last frame has no runtime.Func, it may be an inlined call
+}
+
+// resolvePCs resolves pcs by runtime.CallersFrames.
+//
+// An inlined call has no Func, and the Entry of the function which it
+// is inlined into; runtime.Callers records it with a pc of its own.
+func resolvePCs(pcs []uintptr) (r resolvedPC) {
+	it := runtime.CallersFrames(pcs)
+	for {
+		fr, more := it.Next()
+		if fr.Function != "" || fr.File != "" {
+			if n := len(r.frames); n > 0 && r.funcNil && fr.Entry != 0 && r.frames[n-1].Entry == fr.Entry {
+				r.frames[n-1].Inlined = true
+			}
+			r.frames = append(r.frames, ResolvedFrame{
+				Function: fr.Function,
+				Package:  packageName(fr.Function),
+				File:     fr.File,
+				Line:     fr.Line,
+				Entry:    fr.Entry,
+			})
+			r.funcNil = fr.Func == nil
+		}
+		if !more {
+			break
+		}
+	}
+	return
+}
+
 // StackTrace is Stack of Frames from innermost (newest) to outermost (oldest).
 type StackTrace []Frame
 
@@ -89,22 +176,40 @@ type StackTrace []Frame
 // Format accepts flags that alter the printing of some verbs, as follows:
 //
 //	%+v   Prints filename, function, and line number for each Frame in the Stack.
+//
+// The frames matched by the package filter are elided, see
+// SetFrameFilter. The frames omitted by the stack depth are not told,
+// since only the error holding the stack counts them, see
+// SetStackDepth.
 func (st StackTrace) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		switch {
 		case s.Flag('+'):
-			for _, f := range st {
+			for _, f := range GetFrameFilter().Filter(st.Frames()) {
 				_, _ = fmt.Fprintf(s, "\n%+v", f)
 			}
 		case s.Flag('#'):
 			_, _ = fmt.Fprintf(s, "%#v", []Frame(st))
 		default:
-			_, _ = fmt.Fprintf(s, "%v", []Frame(st))
+			_, _ = fmt.Fprintf(s, "%v", GetFrameFilter().Filter(st.Frames()))
 		}
 	case 's':
-		_, _ = fmt.Fprintf(s, "%s", []Frame(st))
+		_, _ = fmt.Fprintf(s, "%s", GetFrameFilter().Filter(st.Frames()))
+	}
+}
+
+// Frames returns the frames resolved from st, with the inlined calls
+// expanded.
+func (st StackTrace) Frames() []ResolvedFrame {
+	if len(st) == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, len(st))
+	for i, f := range st {
+		pcs[i] = uintptr(f)
 	}
+	return resolveFrames(pcs)
 }
 
 // Stack represents a Stack of program counters.
@@ -118,17 +223,40 @@ type Stack []uintptr
 // Format accepts flags that alter the printing of some verbs, as follows:
 //
 //	%+v   Prints filename, function, and line number for each Frame in the stack.
+//
+// The frames matched by the package filter are elided, see
+// SetFrameFilter. The frames omitted by the stack depth are not told,
+// since only the error holding the stack counts them, see
+// SetStackDepth.
 func (s *Stack) Format(st fmt.State, verb rune) {
+	s.format(st, verb, GetFrameFilter())
+}
+
+func (s *Stack) format(st fmt.State, verb rune, ff *FrameFilter) {
+	if s == nil {
+		return
+	}
 	if verb == 'v' && st.Flag('+') {
-		for _, pc := range *s {
-			f := Frame(pc)
+		for _, f := range ff.Filter(s.Frames()) {
 			_, _ = fmt.Fprintf(st, "\n%+v", f)
 		}
 	}
 }
 
+// Frames returns the frames resolved from s, with the inlined calls
+// expanded.
+func (s *Stack) Frames() []ResolvedFrame {
+	if s == nil || len(*s) == 0 {
+		return nil
+	}
+	return resolveFrames(*s)
+}
+
 // StackTrace returns the stacktrace frames
 func (s *Stack) StackTrace() StackTrace {
+	if s == nil {
+		return nil
+	}
 	f := make([]Frame, len(*s))
 	for i := 0; i < len(f); i++ {
 		f[i] = Frame((*s)[i])
@@ -136,12 +264,117 @@ func (s *Stack) StackTrace() StackTrace {
 	return f
 }
 
-func callers(skip int) *Stack {
-	const depth = 32
-	var pcs [depth]uintptr
-	n := runtime.Callers(2+skip, pcs[:]) // by default, we skip these frames: callers(), and runtime.Callers()
-	var st Stack = pcs[0:n]
-	return &st
+const (
+	// DefaultStackDepth is the default maximum number of frames
+	// recorded in a Stack.
+	DefaultStackDepth = 32
+	// UnlimitedStackDepth records all frames of a stack.
+	UnlimitedStackDepth = -1
+)
+
+var stackDepth int32 = DefaultStackDepth
+
+// SetStackDepth sets the maximum number of frames recorded by New,
+// Wrap and the others. The frames beyond it are omitted, and %+v
+// tells how many they are. UnlimitedStackDepth (or any negative
+// number) records all frames. A zero depth restores
+// DefaultStackDepth.
+//
+// It can be overridden by a builder, see Builder.WithStackDepth.
+func SetStackDepth(depth int) {
+	if depth == 0 {
+		depth = DefaultStackDepth
+	}
+	atomic.StoreInt32(&stackDepth, int32(depth))
+}
+
+// StackDepth returns the maximum number of frames recorded, see
+// SetStackDepth.
+func StackDepth() int { return int(atomic.LoadInt32(&stackDepth)) }
+
+// stackOpts are the options of a call site to record the stack, the
+// zero values are for the package settings.
+type stackOpts struct {
+	depth  int           // see SetStackDepth
+	policy CapturePolicy // see SetCapturePolicy
+}
+
+// callers records the stack of the caller, skip is the number of
+// frames to skip, 0 identifies the caller of callers. At most
+// o.depth frames are recorded, 0 for StackDepth(), negative for
+// unlimited. It returns the number of the outer frames omitted too,
+// they are counted only if the stack is deeper than o.depth.
+//
+// The capture policy may record the caller frame only, without
+// counting the omitted frames, or nothing (a nil Stack). The frames
+// of the helpers on the top are skipped, see Helper.
+func callers(skip int, o stackOpts) (st *Stack, omitted int) {
+	policy := o.policy.resolve()
+	if policy == CaptureNone {
+		return nil, 0
+	}
+
+	depth := o.depth
+	if depth == 0 {
+		depth = StackDepth()
+	}
+	if policy == CaptureCaller {
+		depth = 1
+	}
+	size := 64 // unlimited, the buffer grows until the whole stack fits
+	switch {
+	case policy == CaptureCaller:
+		size = 1
+	case depth > 0:
+		size = depth + 1 // one more to tell whether any frame is omitted
+	}
+	helping, extra := helpers.active(), 0
+	if helping {
+		extra = helperFrames // the room for the helpers on the top
+	}
+	buf := make([]uintptr, size+extra)
+	var pcs []uintptr
+	var n int
+	for {
+		// by default, we skip these frames: callers(), and runtime.Callers()
+		n = runtime.Callers(2+skip, buf)
+		pcs = buf[:n]
+		if helping {
+			pcs = pcs[helpers.skip(pcs):]
+		}
+		switch {
+		case n < len(buf): // the whole stack
+		case depth < 0:
+			buf = make([]uintptr, len(buf)*2)
+			continue
+		case len(pcs) < size: // the helpers on the top took the room
+			buf = make([]uintptr, n-len(pcs)+size+extra)
+			continue
+		}
+		break
+	}
+	if depth > 0 && len(pcs) > depth {
+		if policy != CaptureCaller {
+			// the helpers on the top are not counted
+			omitted = stackSize(1+skip, 2*len(buf)) - (n - len(pcs)) - depth
+		}
+		pcs = append([]uintptr(nil), pcs[:depth]...)
+	}
+	s := Stack(pcs)
+	return &s, omitted
+}
+
+// stackSize returns the number of frames of the stack of the caller
+// identified by skip, 0 for the caller of stackSize. The buffer grows
+// from size until the whole stack fits.
+func stackSize(skip, size int) int {
+	buf := make([]uintptr, size)
+	for {
+		if n := runtime.Callers(2+skip, buf); n < len(buf) {
+			return n
+		}
+		buf = make([]uintptr, len(buf)*2)
+	}
 }
 
 // funcname removes the path prefix component of a function's name reported by func.Name().
@@ -3,6 +3,7 @@ package errors
 import (
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 )
 
@@ -12,7 +13,7 @@ func TestFuncname(t *testing.T) {
 }
 
 func TestStack_StackTrace(t *testing.T) {
-	s := callers(0)
+	s, _ := callers(0, stackOpts{})
 	t.Log(s.StackTrace())
 
 	fmt.Printf("%+v\n", s)
@@ -68,3 +69,255 @@ func TestWithStackInfo(t *testing.T) {
 	fmt.Printf("+v: %+v\n", err)
 	fmt.Printf("#v: %#v\n", err)
 }
+
+// inlinedCallers is small enough to be inlined into its caller.
+func inlinedCallers() *Stack {
+	s, _ := callers(0, stackOpts{})
+	return s
+}
+
+func TestResolvedFrames(t *testing.T) {
+	s := inlinedCallers()
+	frames := s.Frames()
+	if len(frames) < 2 {
+		t.Fatalf("bad frames: %+v", frames)
+	}
+	if f := frames[0]; !strings.HasSuffix(f.Function, ".inlinedCallers") ||
+		f.Package != "gopkg.in/hedzr/errors.v3" || !strings.HasSuffix(f.File, "stack_test.go") {
+		t.Fatalf("bad frame 0: %+v", f)
+	}
+	if f := frames[1]; !strings.HasSuffix(f.Function, ".TestResolvedFrames") || f.Inlined {
+		t.Fatalf("bad frame 1: %#v", f)
+	}
+	if frames[0].Inlined != (frames[0].Entry == frames[1].Entry) {
+		t.Fatalf("bad inlined flag: %v, entries: %#x, %#x", frames[0].Inlined, frames[0].Entry, frames[1].Entry)
+	}
+
+	if got := fmt.Sprintf("%+v", s); !strings.Contains(got, ".inlinedCallers\n\t") || !strings.Contains(got, ".TestResolvedFrames\n\t") {
+		t.Fatalf("bad %%+v: %s", got)
+	}
+
+	f := s.StackTrace()[0]
+	line := frames[0].Line
+	for format, want := range map[string]string{
+		"%s":  "stack_test.go",
+		"%d":  fmt.Sprint(line),
+		"%n":  "inlinedCallers",
+		"%v":  fmt.Sprintf("stack_test.go:%d", line),
+		"%+v": fmt.Sprintf("%s\n\t%s:%d", frames[0].Function, frames[0].File, line),
+	} {
+		if got := fmt.Sprintf(format, f); got != want {
+			t.Errorf("%s: got %q, want %q", format, got, want)
+		}
+	}
+
+	if r := Frame(0).Resolve(); r.Function != "unknown" || r.File != "unknown" {
+		t.Fatalf("bad unknown frame: %+v", r)
+	}
+}
+
+func recurse(n int, fn func() error) error {
+	if n == 0 {
+		return fn()
+	}
+	return recurse(n-1, fn)
+}
+
+func TestStackDepth(t *testing.T) {
+	defer SetStackDepth(0)
+
+	SetStackDepth(UnlimitedStackDepth)
+	full := recurse(40, func() error { return New("deep") }).(*WithStackInfo)
+	SetStackDepth(5)
+	err := recurse(40, func() error { return New("deep") })
+	w := err.(*WithStackInfo)
+	if len(*w.Stack) != 5 || w.omitted != len(*full.Stack)-5 {
+		t.Fatalf("bad stack: %d frames, %d omitted of %d", len(*w.Stack), w.omitted, len(*full.Stack))
+	}
+	if s := fmt.Sprintf("%+v", err); !strings.HasSuffix(s, fmt.Sprintf("\n... %d frames omitted", w.omitted)) {
+		t.Fatalf("no truncation marker: %s", s)
+	}
+	if s := fmt.Sprintf("%+v", New("shallow")); strings.Contains(s, "omitted") {
+		t.Fatalf("unexpected truncation marker: %s", s)
+	}
+	data, _ := MarshalJSONWith(err)
+	if back, _ := UnmarshalJSONError(data); !strings.Contains(string(data), fmt.Sprintf(`"stack_omitted":%d`, w.omitted)) ||
+		back.(*WithStackInfo).omitted != w.omitted {
+		t.Fatalf("the truncation is not encoded: %s", data)
+	}
+
+	SetStackDepth(UnlimitedStackDepth)
+	w = recurse(200, func() error { return Wrap(io.EOF, "deep") }).(*WithStackInfo)
+	if len(*w.Stack) < 200 || w.omitted != 0 {
+		t.Fatalf("bad unlimited stack: %d frames, %d omitted", len(*w.Stack), w.omitted)
+	}
+
+	SetStackDepth(0)
+	if StackDepth() != DefaultStackDepth {
+		t.Fatalf("bad default depth: %d", StackDepth())
+	}
+	w = recurse(100, func() error { return NewBuilder().WithStackDepth(3).Build() }).(*WithStackInfo)
+	if len(*w.Stack) != 3 || w.omitted < 100 {
+		t.Fatalf("bad builder stack: %d frames, %d omitted", len(*w.Stack), w.omitted)
+	}
+	w = recurse(100, func() error { return New(WithStackDepth(UnlimitedStackDepth)) }).(*WithStackInfo)
+	if len(*w.Stack) < 100 || w.omitted != 0 {
+		t.Fatalf("bad option stack: %d frames, %d omitted", len(*w.Stack), w.omitted)
+	}
+}
+
+func TestCapturePolicy(t *testing.T) {
+	defer SetCapturePolicy(CaptureDefault)
+	defer SetCaptureSampleRate(100)
+
+	frames := func(err error) int {
+		if s := err.(*WithStackInfo).Stack; s != nil {
+			return len(*s)
+		}
+		return -1
+	}
+
+	SetCapturePolicy(CaptureNone)
+	if n := frames(New("x")); n != -1 {
+		t.Fatalf("CaptureNone: %d frames", n)
+	}
+	if s := fmt.Sprintf("%+v", Wrap(io.EOF, "x")); strings.Contains(s, ".go:") {
+		t.Fatalf("CaptureNone: %s", s)
+	}
+	if n := frames(New(WithCapturePolicy(CaptureFull))); n < 2 {
+		t.Fatalf("CaptureFull per call site: %d frames", n)
+	}
+
+	SetCapturePolicy(CaptureCaller)
+	err := New("x")
+	if n := frames(err); n != 1 || !strings.HasSuffix(err.(*WithStackInfo).Frames()[0].Function, ".TestCapturePolicy") {
+		t.Fatalf("CaptureCaller: %+v", err)
+	}
+	if s := fmt.Sprintf("%+v", err); strings.Contains(s, "omitted") {
+		t.Fatalf("CaptureCaller: %s", s)
+	}
+	if n := frames(NewBuilder().WithCapturePolicy(CaptureNone).Build()); n != -1 {
+		t.Fatalf("CaptureNone per call site: %d frames", n)
+	}
+
+	SetCapturePolicy(CaptureSampled)
+	SetCaptureSampleRate(4)
+	var full int
+	for i := 0; i < 40; i++ {
+		if frames(Wrap(io.EOF, "x")) > 1 {
+			full++
+		}
+	}
+	if full != 10 {
+		t.Fatalf("CaptureSampled: %d full stacks in 40 errors", full)
+	}
+	if GetCapturePolicy().String() != "SAMPLED" {
+		t.Fatalf("bad policy: %v", GetCapturePolicy())
+	}
+}
+
+func TestWrapPolicy(t *testing.T) {
+	defer SetWrapPolicy(WrapFull)
+
+	frames := func(err error) int { return len(*err.(*WithStackInfo).Stack) }
+	inner := New("inner")
+
+	if n := frames(Wrap(inner, "outer")); n < 2 {
+		t.Fatalf("WrapFull: %d frames", n)
+	}
+
+	SetWrapPolicy(WrapCaller)
+	for name, err := range map[string]error{
+		"Wrap":      Wrap(inner, "outer"),
+		"Wrapf":     Wrapf(inner, "outer"),
+		"WithCause": WithCause(inner, "outer"),
+		"WithStack": WithStack(inner),
+		"Errorf":    Errorf("outer: %w", inner),
+		"New":       New(WithErrors(inner)),
+		"deep":      Wrap(&causerErr{inner}, "outer"),
+	} {
+		if n := frames(err); n != 1 {
+			t.Errorf("%s: %d frames", name, n)
+		}
+	}
+	if n := frames(Wrap(io.EOF, "outer")); n < 2 {
+		t.Fatalf("a cause without stack: %d frames", n)
+	}
+	if n := frames(New(WithCapturePolicy(CaptureFull), WithErrors(inner))); n < 2 {
+		t.Fatalf("the policy of the call site: %d frames", n)
+	}
+
+	text := fmt.Sprintf("%+v", Wrap(inner, "outer"))
+	if i := strings.Index(text, "Caused by: inner"); i < 0 || !strings.Contains(text[i:], "TestWrapPolicy") {
+		t.Fatalf("the trace of the inner error should be printed:\n%s", text)
+	}
+}
+
+func benchmarkCapture(b *testing.B, p CapturePolicy) {
+	SetCapturePolicy(p)
+	defer SetCapturePolicy(CaptureDefault)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = recurse(10, func() error { return New("token %d", i) })
+	}
+}
+
+func BenchmarkCaptureFull(b *testing.B)    { benchmarkCapture(b, CaptureFull) }
+func BenchmarkCaptureCaller(b *testing.B)  { benchmarkCapture(b, CaptureCaller) }
+func BenchmarkCaptureNone(b *testing.B)    { benchmarkCapture(b, CaptureNone) }
+func BenchmarkCaptureSampled(b *testing.B) { benchmarkCapture(b, CaptureSampled) }
+
+//go:noinline
+func layerInner() error { return New("inner") }
+
+//go:noinline
+func layerOuter() error {
+	err := New("outer")
+	err.Attach(layerInner())
+	return err
+}
+
+func TestLayeredStacks(t *testing.T) {
+	inner := layerInner().(*WithStackInfo)
+	w := New("outer").(*WithStackInfo)
+	st := w.Stack
+	w.Attach(inner)
+	_ = w.WithData(inner)
+	if w.Stack != st {
+		t.Fatal("Attach and WithData should keep the stack of the wrapping site")
+	}
+
+	text := fmt.Sprintf("%+v", layerOuter())
+	t.Log(text)
+	i, j := strings.Index(text, "layerOuter"), strings.Index(text, "Caused by: inner")
+	if i < 0 || j < i || !strings.Contains(text[j:], "layerInner") || !strings.Contains(text[j:], "layerOuter") {
+		t.Fatalf("expect the outer stack, then the inner one:\n%s", text)
+	}
+	if !strings.Contains(text[:j], "... 1 frame shared with cause") || strings.Contains(text[:j], "TestLayeredStacks") {
+		t.Fatalf("the shared frames should be elided from the outer stack:\n%s", text)
+	}
+	if !strings.Contains(text[j:], "TestLayeredStacks") {
+		t.Fatalf("the inner stack should be printed in full:\n%s", text)
+	}
+
+	// the stacks cut by the same number of frames are still compared
+	SetStackDepth(4)
+	err := recurse(10, func() error {
+		err := New("outer")
+		err.Attach(New("inner"))
+		return err
+	})
+	SetStackDepth(0)
+	text = fmt.Sprintf("%+v", err)
+	j = strings.Index(text, "Caused by: inner")
+	omitted := fmt.Sprintf("... %d frames omitted", err.(*WithStackInfo).omitted)
+	if !strings.Contains(text[:j], "... 3 frames shared with cause\n"+omitted) || !strings.HasSuffix(text, omitted) {
+		t.Fatalf("expect both the shared frames and the omitted ones:\n%s", text)
+	}
+
+	// the layers under a foreign error
+	text = fmt.Sprintf("%+v", Wrap(&causerErr{layerInner()}, "wrapped"))
+	if !strings.Contains(text, "Caused by: inner") {
+		t.Fatalf("expect the layer under the foreign error:\n%s", text)
+	}
+}
@@ -39,14 +39,14 @@ func CanCauses(err interface{}) (ok bool) { //nolint:revive
 // Causes simply returns the wrapped inner errors.
 // It doesn't consider an wrapped Code entity is an inner error too.
 // So if you wanna to extract any inner error objects, use
-// errors.Unwrap for instead. The errors.Unwrap could extract all
-// of them one by one:
+// errors.Walk for instead. The errors.Walk visits all of them,
+// including the nested ones:
 //
 //	var err = errors.New("hello").WithErrors(io.EOF, io.ShortBuffers)
-//	var e error = err
-//	for e != nil {
-//	    e = errors.Unwrap(err)
-//	}
+//	_ = errors.Walk(err, func(e error, depth int, path []int, parent error) error {
+//	    // process e...
+//	    return nil
+//	})
 func Causes(err error) (errs []error) {
 	if e, ok := err.(causers); ok {
 		errs = e.Causes()
@@ -69,6 +69,9 @@ func Causes(err error) (errs []error) {
 // CanUnwrap tests if err is unwrap-able
 func CanUnwrap(err interface{}) (ok bool) { //nolint:revive
 	_, ok = err.(interface{ Unwrap() error })
+	if !ok {
+		_, ok = err.(interface{ Unwrap() []error })
+	}
 	return
 }
 
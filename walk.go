@@ -0,0 +1,130 @@
+// Copyright © 2023 Hedzr Yeh.
+
+package errors
+
+import (
+	"errors"
+	"reflect"
+)
+
+// SkipChildren can be returned by a WalkFunc to tell Walk not to
+// descend into the inner errors of the current one.
+var SkipChildren = errors.New("skip children") //nolint:revive
+
+// SkipAll can be returned by a WalkFunc to stop Walk immediately.
+// Walk returns nil in this case.
+var SkipAll = errors.New("skip all") //nolint:revive
+
+// WalkFunc is the type of the function called by Walk for each error
+// in an error tree.
+//
+// depth is 0 for the root error. path holds the indexes of the nodes
+// from the root to err, so it is empty for the root; it is reused
+// between calls, copy it if you need to keep it. parent is nil for
+// the root.
+//
+// Returning SkipChildren skips the inner errors of err, returning
+// SkipAll stops the walking. Any other non-nil error stops the walking
+// and will be returned by Walk.
+type WalkFunc func(err error, depth int, path []int, parent error) error
+
+// Walk visits err and all of its inner errors in depth-first order,
+// calling fn for each of them.
+//
+// The inner errors are:
+//
+//  1. the Causers and the non-OK Code of an errors.Error,
+//  2. the errors returned by Unwrap() []error or Unwrap() error,
+//  3. the cause returned by Cause() error (pkg/errors style).
+//
+// An error which is one of its own ancestors is skipped, so Walk is
+// safe on a malformed cyclic error tree. The same error attached at
+// different places is visited for each of them.
+//
+// A sample to enumerate all leaf errors:
+//
+//	err := errors.New("many").WithErrors(io.EOF, errors.New("nested").WithErrors(io.ErrShortWrite))
+//	var leaves []error
+//	_ = errors.Walk(err, func(e error, depth int, path []int, parent error) error {
+//	    if len(errors.Children(e)) == 0 {
+//	        leaves = append(leaves, e)
+//	    }
+//	    return nil
+//	})
+func Walk(err error, fn WalkFunc) error {
+	if err == nil {
+		return nil
+	}
+	e := walk(err, nil, 0, make([]int, 0, 8), fn, nil)
+	if e == SkipAll || e == SkipChildren { //nolint:errorlint
+		return nil
+	}
+	return e
+}
+
+func walk(err, parent error, depth int, path []int, fn WalkFunc, ancestors visitedSet) error {
+	if !ancestors.enter(err, nil) {
+		return nil // a cyclic reference
+	}
+	defer ancestors.leave(err, nil)
+
+	if e := fn(err, depth, path, parent); e != nil {
+		if e == SkipChildren { //nolint:errorlint
+			return nil
+		}
+		return e
+	}
+	for i, child := range Children(err) {
+		if child == nil {
+			continue
+		}
+		if e := walk(child, err, depth+1, append(path, i), fn, ancestors); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// Children returns the direct inner errors of err, in the order which
+// Walk visits them. It never mutates err.
+func Children(err error) []error {
+	if errs := unwrapErrors(err); len(errs) > 0 {
+		return errs
+	}
+	if c, ok := err.(causer); ok {
+		if e := c.Cause(); e != nil && !sameError(e, err) {
+			return []error{e}
+		}
+	}
+	return nil
+}
+
+// sameError tests whether a and b are the identical error object.
+// Unlike a == b, it never panics on incomparable error types.
+func sameError(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ta := reflect.TypeOf(a)
+	if ta != reflect.TypeOf(b) || !ta.Comparable() {
+		return false
+	}
+	return a == b //nolint:errorlint
+}
+
+// FindFunc returns the first error in err's tree which satisfies
+// pred, in the order of Walk. It returns nil if nothing found.
+//
+//	e := errors.FindFunc(err, func(e error) bool {
+//	    return strings.HasPrefix(e.Error(), "quota")
+//	})
+func FindFunc(err error, pred func(error) bool) (found error) {
+	_ = Walk(err, func(e error, depth int, path []int, parent error) error {
+		if pred(e) {
+			found = e
+			return SkipAll
+		}
+		return nil
+	})
+	return
+}
@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type causerErr struct{ cause error }
+
+func (e *causerErr) Error() string { return "causer: " + e.cause.Error() }
+func (e *causerErr) Cause() error  { return e.cause }
+
+func TestWalk(t *testing.T) {
+	nested := New("nested").WithErrors(io.ErrShortWrite, &causerErr{io.ErrNoProgress})
+	err := New("root").WithErrors(io.EOF, nested).WithCode(Internal)
+
+	type visit struct {
+		err    error
+		depth  int
+		path   []int
+		parent error
+	}
+	var visits []visit
+	e := Walk(err, func(e error, depth int, path []int, parent error) error {
+		visits = append(visits, visit{e, depth, append([]int(nil), path...), parent})
+		return nil
+	})
+	if e != nil {
+		t.Fatalf("Walk returns unexpected error: %v", e)
+	}
+
+	expects := []visit{
+		{err, 0, nil, nil},
+		{io.EOF, 1, []int{0}, err},
+		{nested, 1, []int{1}, err},
+		{io.ErrShortWrite, 2, []int{1, 0}, nested},
+		{Causes(nested)[1], 2, []int{1, 1}, nested},
+		{io.ErrNoProgress, 3, []int{1, 1, 0}, Causes(nested)[1]},
+		{Internal, 1, []int{2}, err},
+	}
+	if len(visits) != len(expects) {
+		t.Fatalf("expecting %d visits, but got %d: %v", len(expects), len(visits), visits)
+	}
+	for i, v := range visits {
+		x := expects[i]
+		if v.err != x.err || v.depth != x.depth || v.parent != x.parent || !reflect.DeepEqual(v.path, x.path) {
+			t.Fatalf("%d. expecting %+v, but got %+v", i, x, v)
+		}
+	}
+}
+
+func TestWalkSkip(t *testing.T) {
+	_, e1 := strconv.Atoi("x")
+	nested := New("nested").WithErrors(io.ErrShortWrite, e1)
+	err := New("root").WithErrors(nested, io.EOF, io.ErrClosedPipe)
+
+	var leaves []error
+	_ = Walk(err, func(e error, depth int, path []int, parent error) error {
+		if e == nested { //nolint:errorlint
+			return SkipChildren
+		}
+		if len(Children(e)) == 0 {
+			leaves = append(leaves, e)
+		}
+		return nil
+	})
+	if !reflect.DeepEqual(leaves, []error{io.EOF, io.ErrClosedPipe}) {
+		t.Fatalf("SkipChildren failed, leaves = %v", leaves)
+	}
+
+	var count int
+	e := Walk(err, func(e error, depth int, path []int, parent error) error {
+		count++
+		if e == io.EOF { //nolint:errorlint
+			return SkipAll
+		}
+		return nil
+	})
+	if e != nil || count != 6 {
+		t.Fatalf("SkipAll failed, e = %v, count = %d", e, count)
+	}
+
+	if e = Walk(err, func(e error, depth int, path []int, parent error) error { return io.ErrUnexpectedEOF }); e != io.ErrUnexpectedEOF { //nolint:errorlint
+		t.Fatalf("expecting Walk returns the error from fn, but got %v", e)
+	}
+}
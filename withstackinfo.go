@@ -15,6 +15,12 @@ type WithStackInfo struct {
 
 	sites       []interface{}          //nolint:revive
 	taggedSites map[string]interface{} //nolint:revive
+
+	omitted    int          // the number of the outer frames omitted from Stack, see SetStackDepth
+	filter     *FrameFilter // the filter of the printed stack, nil for the package filter
+	remote     RemoteStack  // the stack decoded from another process
+	remoteType string       // the original Go type of a decoded error
+	spawned    *Stack       // the stack of the caller of Go
 }
 
 func (w *WithStackInfo) IsDescended(descendant error) bool {
@@ -39,7 +45,9 @@ func withStackZ(cause error) *WithStackInfo {
 	if cause == nil {
 		return nil
 	}
-	return &WithStackInfo{causes2: causes2{Causers: []error{cause}}, Stack: callers(1)}
+	w := &WithStackInfo{causes2: causes2{Causers: []error{cause}}}
+	w.Stack, w.omitted = callers(1, stackOpts{}.wrapping(cause))
+	return w
 }
 
 // End ends the WithXXX stream calls while you dislike unwanted `err =`.
@@ -92,10 +100,26 @@ func (w *WithStackInfo) rebuild() Buildable {
 // WithSkip specifies a special number of stack frames that will
 // be ignored.
 func (w *WithStackInfo) WithSkip(skip int) Buildable {
-	w.Stack = callers(skip)
+	w.Stack, w.omitted = callers(skip, stackOpts{})
 	return w
 }
 
+// WithFrameFilter specifies the filter to elide the frames from the
+// stack printed by %+v, it overrides the package filter (see
+// SetFrameFilter). An empty FrameFilter prints all frames, and nil
+// restores the package filter.
+func (w *WithStackInfo) WithFrameFilter(ff *FrameFilter) Buildable {
+	w.filter = ff
+	return w
+}
+
+func (w *WithStackInfo) frameFilter() *FrameFilter {
+	if w.filter != nil {
+		return w.filter
+	}
+	return GetFrameFilter()
+}
+
 // WithMessage formats the error message
 func (w *WithStackInfo) WithMessage(message string, args ...interface{}) Buildable { //nolint:revive
 	_ = w.causes2.WithMessage(message, args...)
@@ -112,28 +136,25 @@ func (w *WithStackInfo) WithCode(code Code) Buildable {
 
 // Attach collects the errors except an error is nil.
 //
-// StackTrace of errs will be copied to callee so that you can get a
-// trace output nearer by the last error.
+// w keeps its own stack, the stacks of errs are kept by themselves,
+// and %+v prints all of them layer by layer.
 //
 // Since v3.0.5, we break Attach() and remove its returning value.
 // So WithStackInfo is a Container compliant type now.
+//
+// Attaching w itself, or an error which holds w, is denied since it
+// makes a cyclic error tree.
 func (w *WithStackInfo) Attach(errs ...error) {
 	// _ = w.WithErrors(errs...)
 
 	for _, e := range errs {
 		if e != nil {
-			if x, ok := e.(*WithStackInfo); ok && x == w {
+			if w.wouldCycle(e) {
 				continue
 			}
 			w.Causers = append(w.Causers, e)
 		}
 	}
-
-	for _, e := range errs {
-		if x, ok := e.(*WithStackInfo); ok && x != w {
-			w.Stack = x.Stack
-		}
-	}
 }
 
 // WithErrors attaches the given errs as inner errors.
@@ -172,10 +193,12 @@ func (w *WithStackInfo) WithErrors(errs ...error) Buildable {
 
 // WithData appends errs if the general object is a error object.
 //
-// StackTrace of errs will be copied to callee so that you can get a
-// trace output nearer by the last error.
+// w keeps its own stack, the stacks of errs are kept by themselves,
+// and %+v prints all of them layer by layer.
 //
 // defer-recover block typically is a better place of WithData().
+// Recover and FromPanic make the error from a panic for the common
+// case.
 //
 // For example:
 //
@@ -183,7 +206,7 @@ func (w *WithStackInfo) WithErrors(errs ...error) Buildable {
 //	  if e := recover(); e != nil {
 //	    err = errors.New("[recovered] copyTo unsatisfied ([%v] %v -> [%v] %v), causes: %v",
 //	      c.indirectType(from.Type()), from, c.indirectType(to.Type()), to, e).
-//	      WithData(e)                 // StackTrace of e -> err
+//	      WithData(e)                 // e is attached as a cause if it is an error
 //	    n := log.CalcStackFrames(1)   // skip defer-recover frame at first
 //	    log.Skip(n).Errorf("%v", err) // skip go-lib frames and defer-recover frame, back to the point throwing panic
 //	  }
@@ -192,10 +215,10 @@ func (w *WithStackInfo) WithData(errs ...interface{}) Buildable { //nolint:reviv
 	if len(errs) > 0 {
 		for _, e := range errs {
 			if e1, ok := e.(error); ok {
-				_ = w.WithErrors(e1)
-				if e1, ok := e.(*WithStackInfo); ok {
-					w.Stack = e1.Stack
+				if w.wouldCycle(e1) {
+					continue
 				}
+				_ = w.WithErrors(e1)
 			} else if e != nil {
 				w.sites = append(w.sites, e)
 			}
@@ -216,7 +239,13 @@ func (w *WithStackInfo) WithTaggedData(siteScenes TaggedData) Buildable {
 }
 
 // WithCause sets the underlying error manually if necessary.
+//
+// A cause which holds w itself will be ignored, since it makes a
+// cyclic error tree.
 func (w *WithStackInfo) WithCause(cause error) Buildable {
+	if cause != nil && w.wouldCycle(cause) {
+		return w
+	}
 	w.causes2.Causers = append(w.causes2.Causers, cause)
 	return w
 }
@@ -263,6 +292,7 @@ func (w *WithStackInfo) Defer(err *error) { //nolint:gocritic
 
 func (w *WithStackInfo) Clear() Container {
 	w.msg = ""
+	w.msgCauses, w.wrapsCause = 0, false
 	w.sites = nil
 	w.taggedSites = nil
 	w.Causers = nil
@@ -291,10 +321,17 @@ func (w *WithStackInfo) Clone() *WithStackInfo {
 			msg:         w.causes2.msg,
 			unwrapIndex: w.causes2.unwrapIndex,
 			liveArgs:    w.causes2.liveArgs,
+			msgCauses:   w.causes2.msgCauses,
+			wrapsCause:  w.causes2.wrapsCause,
 		},
 		Stack:       w.Stack,
+		omitted:     w.omitted,
+		filter:      w.filter,
 		sites:       w.sites,
 		taggedSites: w.taggedSites,
+		remote:      w.remote,
+		remoteType:  w.remoteType,
+		spawned:     w.spawned,
 	}
 	return c
 }
@@ -341,7 +378,7 @@ func (w *WithStackInfo) Format(s fmt.State, verb rune) { //nolint:revive
 				}
 			}
 			_, _ = fmt.Fprint(s, sb.String())
-			w.Stack.Format(s, verb)
+			w.formatLayers(s, make(visitedSet))
 			return
 		}
 		_, _ = fmt.Fprintf(s, "%v", w.Error())
@@ -354,26 +391,36 @@ func (w *WithStackInfo) Format(s fmt.State, verb rune) { //nolint:revive
 
 // Is reports whether any error in `err`'s chain matches target.
 func (w *WithStackInfo) Is(target error) bool {
-	if te, ok := target.(*WithStackInfo); ok {
-		return w.equal(te)
+	var visited visitedSet
+	_ = visited.enter(w, target)
+	if w.isSelf(target, visited) {
+		return true
 	}
 	for _, e := range w.Causers {
-		if Is(e, target) {
+		if isV(e, target, visited) {
 			return true
 		}
 	}
-	return w.causes2.Is(target)
+	return false
 }
 
-func (w *WithStackInfo) equal(target *WithStackInfo) bool {
-	if w.causes2.equal(&target.causes2) &&
+// isSelf tests target against w itself but not its inner errors.
+func (w *WithStackInfo) isSelf(target error, visited visitedSet) bool {
+	if te, ok := target.(*WithStackInfo); ok {
+		return w.equal(te, visited)
+	}
+	return w.causes2.isSelf(target, visited)
+}
+
+func (w *WithStackInfo) equal(target *WithStackInfo, visited visitedSet) bool {
+	if w.causes2.equal(&target.causes2, visited) &&
 		reflect.DeepEqual(w.sites, target.sites) &&
 		reflect.DeepEqual(w.taggedSites, target.taggedSites) {
 		return true
 	}
 
 	for _, e := range w.Causers {
-		if Is(target, e) {
+		if isV(target, e, visited) {
 			return true
 		}
 	}
@@ -439,3 +486,86 @@ func (w *WithStackInfo) equal(target *WithStackInfo) bool {
 //	}
 //	return false
 // }
+
+// formatLayers prints the stack of w, then the stacks of the nearest
+// errors with stacks under w, each one introduced by a "Caused by"
+// line, and the stack of the caller of Go at last. The frames which w
+// shares with a cause are elided from w, since they are printed by
+// the cause.
+func (w *WithStackInfo) formatLayers(s fmt.State, visited visitedSet) {
+	if !visited.enter(w, nil) {
+		return
+	}
+	defer visited.leave(w, nil)
+
+	layers := w.causeLayers()
+	shared := 0
+	for _, c := range layers {
+		if n := w.sharedFrames(c); n > shared {
+			shared = n
+		}
+	}
+
+	ff := w.frameFilter()
+	if w.Stack != nil {
+		pcs := *w.Stack
+		for _, f := range ff.Filter(resolveFrames(pcs[:len(pcs)-shared])) {
+			_, _ = fmt.Fprintf(s, "\n%+v", f)
+		}
+		if shared > 0 {
+			if n := len(ff.Filter(resolveFrames(pcs[len(pcs)-shared:]))); n > 0 {
+				_, _ = fmt.Fprintf(s, "\n... %s shared with cause", nFrames(n))
+			}
+		}
+	}
+	w.remote.Format(s, 'v')
+	if w.omitted > 0 {
+		_, _ = fmt.Fprintf(s, "\n... %s omitted", nFrames(w.omitted))
+	}
+
+	for _, c := range layers {
+		_, _ = fmt.Fprintf(s, "\nCaused by: %v", c.Error())
+		c.formatLayers(s, visited)
+	}
+	w.formatSpawned(s)
+}
+
+// causeLayers returns the nearest errors with stacks in the causes of
+// w, the foreign errors are walked through.
+func (w *WithStackInfo) causeLayers() (layers []*WithStackInfo) {
+	for _, c := range w.Causers {
+		if c == nil {
+			continue
+		}
+		_ = Walk(c, func(e error, depth int, path []int, parent error) error {
+			if x, ok := e.(*WithStackInfo); ok && x != w && (x.Stack != nil || len(x.remote) > 0 || x.spawned != nil) {
+				layers = append(layers, x)
+				return SkipChildren
+			}
+			return nil
+		})
+	}
+	return
+}
+
+// sharedFrames returns the length of the common suffix of the stacks
+// of w and c. The stacks truncated by different numbers of frames
+// are not compared, since their suffixes are not aligned.
+func (w *WithStackInfo) sharedFrames(c *WithStackInfo) (n int) {
+	if w.Stack == nil || c.Stack == nil || w.omitted != c.omitted {
+		return
+	}
+	a, b := *w.Stack, *c.Stack
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return
+}
+
+// nFrames returns "1 frame" or "n frames".
+func nFrames(n int) string {
+	if n == 1 {
+		return "1 frame"
+	}
+	return fmt.Sprintf("%d frames", n)
+}